@@ -3,9 +3,13 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-python/gpython/ast"
@@ -21,6 +25,12 @@ var (
 	verbose      bool
 	lineno       bool
 	mainpackage  bool
+	useBuilder   bool // use a strings.Builder for string accumulators in loops
+	noAssert     bool // remove the assert statements (as python -O)
+	renameSuffix = "Π"
+
+	pkgName         string // overrides the package name (from the file name or main)
+	buildConstraint string // added as a //go:build line
 
 	gokeywords = map[string]string{
 		// Convert python names to pygor names
@@ -33,35 +43,114 @@ var (
 		"dict":  "Dict",
 		"list":  "List",
 		"tuple": "Tuple",
+	}
+
+	// python builtins that are (or are translated to) Go types, renamed by adding renameSuffix
+	// when used as variable or parameter names (i.e. list = [1, 2, 3] -> listΠ := ...)
+	gobuiltins = map[string]bool{
+		"bool":    true,
+		"bytes":   true,
+		"complex": true,
+		"dict":    true,
+		"float":   true,
+		"int":     true,
+		"list":    true,
+		"set":     true,
+		"str":     true,
+		"tuple":   true,
+		"type":    true,
+	}
 
+	// names that are renamed by adding renameSuffix
+	goreserved = map[string]bool{
 		// these are not go keywords but they are used by pygor
-		"Any":   "AnyΠ",
-		"Dict":  "DictΠ",
-		"List":  "ListΠ",
-		"Tuple": "TupleΠ",
+		"Any":   true,
+		"Dict":  true,
+		"List":  true,
+		"Tuple": true,
 
 		// these are standard package names we may want to preserve
-		"fmt": "fmtΠ",
+		"fmt": true,
 
 		// these are go keywords that need to be renamed
-		"case":        "caseΠ",
-		"chan":        "chanΠ",
-		"const":       "constΠ",
-		"default":     "defaultΠ",
-		"defer":       "deferΠ",
-		"fallthrough": "fallthroughΠ",
-		"func":        "funcΠ",
-		"go":          "goΠ",
-		"goto":        "gotoΠ",
-		"interface":   "interfaceΠ",
-		"map":         "mapΠ",
-		"package":     "packageΠ",
-		"range":       "rangeΠ",
-		"select":      "selectΠ",
-		"struct":      "structΠ",
-		"switch":      "switchΠ",
-		"type":        "typeΠ",
-		"var":         "varΠ",
+		"case":        true,
+		"chan":        true,
+		"const":       true,
+		"default":     true,
+		"defer":       true,
+		"fallthrough": true,
+		"func":        true,
+		"go":          true,
+		"goto":        true,
+		"interface":   true,
+		"map":         true,
+		"package":     true,
+		"range":       true,
+		"select":      true,
+		"struct":      true,
+		"switch":      true,
+		"type":        true,
+		"var":         true,
+	}
+
+	// python module symbols with a Go equivalent (package, name)
+	// more can be added with the -symbols file
+	gosymbols = map[string][2]string{
+		"os.getcwd":        {"os", "Getwd"},
+		"os.getenv":        {"os", "Getenv"},
+		"os.path.join":     {"path/filepath", "Join"},
+		"os.path.basename": {"path/filepath", "Base"},
+		"os.path.dirname":  {"path/filepath", "Dir"},
+		"os.path.exists":   {goRuntime, "Exists"},
+		"os.path.isfile":   {goRuntime, "IsFile"},
+		"os.path.isdir":    {goRuntime, "IsDir"},
+		"os.path.splitext": {goRuntime, "SplitExt"},
+		"sys.exit":         {"os", "Exit"},
+
+		// note that math.floor and math.ceil return an int in python, a float64 in Go
+		"math.pi":       {"math", "Pi"},
+		"math.e":        {"math", "E"},
+		"math.sqrt":     {"math", "Sqrt"},
+		"math.floor":    {"math", "Floor"},
+		"math.ceil":     {"math", "Ceil"},
+		"math.trunc":    {"math", "Trunc"},
+		"math.fabs":     {"math", "Abs"},
+		"math.fmod":     {"math", "Mod"},
+		"math.pow":      {"math", "Pow"},
+		"math.exp":      {"math", "Exp"},
+		"math.log":      {"math", "Log"}, // only with one argument
+		"math.log2":     {"math", "Log2"},
+		"math.log10":    {"math", "Log10"},
+		"math.sin":      {"math", "Sin"},
+		"math.cos":      {"math", "Cos"},
+		"math.tan":      {"math", "Tan"},
+		"math.asin":     {"math", "Asin"},
+		"math.acos":     {"math", "Acos"},
+		"math.atan":     {"math", "Atan"},
+		"math.atan2":    {"math", "Atan2"},
+		"math.sinh":     {"math", "Sinh"},
+		"math.cosh":     {"math", "Cosh"},
+		"math.tanh":     {"math", "Tanh"},
+		"math.hypot":    {"math", "Hypot"},
+		"math.copysign": {"math", "Copysign"},
+		"math.isnan":    {"math", "IsNaN"},
+
+		"logging.DEBUG":    {goRuntime, "LogDebug"},
+		"logging.INFO":     {goRuntime, "LogInfo"},
+		"logging.WARNING":  {goRuntime, "LogWarning"},
+		"logging.ERROR":    {goRuntime, "LogError"},
+		"logging.CRITICAL": {goRuntime, "LogCritical"},
+
+		"random.seed":    {goRuntime, "Seed"},
+		"random.random":  {goRuntime, "Random"},
+		"random.uniform": {goRuntime, "Uniform"},
+		"random.randint": {goRuntime, "RandInt"},
+		"random.choice":  {goRuntime, "Choice"},
+		"random.shuffle": {goRuntime, "Shuffle"},
+
+		"itertools.chain":  {goRuntime, "Chain"},
+		"itertools.count":  {goRuntime, "Count"},   // a channel, iterated with for x := range
+		"itertools.repeat": {goRuntime, "RepeatN"}, // only with the count argument
 	}
 
 	goRuntime = "github.com/raff/pygor/runtime"
@@ -81,6 +170,10 @@ func rename(s string) string {
 		return n
 	}
 
+	if goreserved[s] {
+		return s + renameSuffix
+	}
+
 	return s
 }
 
@@ -88,6 +181,30 @@ func renameId(id ast.Identifier) string {
 	return rename(string(id))
 }
 
+// load python -> Go symbol mappings from a file, one per line: "os.path.join path/filepath Join"
+func loadSymbols(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for i, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		parts := strings.Fields(l)
+		if len(parts) != 3 {
+			return fmt.Errorf("%v:%v: expected python-name go-package go-name", path, i+1)
+		}
+
+		gosymbols[parts[0]] = [2]string{parts[1], parts[2]}
+	}
+
+	return nil
+}
+
 func unknown(typ string, v interface{}) *jen.Statement {
 	msg := fmt.Sprintf("UNKNOWN-%v: %T %#v", typ, v, v)
 
@@ -114,6 +231,27 @@ func trimlines(s py.String) string {
 	return strings.Join(lines, "\n")
 }
 
+// convert a module docstring to a package doc comment
+// (the blank lines between paragraphs are kept, as empty comment lines)
+func packageDoc(pname string, doc py.String) string {
+	var lines []string
+
+	for _, l := range strings.Split(strings.TrimSpace(string(doc)), "\n") {
+		l = strings.TrimSpace(l)
+		if len(lines) == 0 && !strings.HasPrefix(l, "Package "+pname) {
+			l = strings.TrimSpace("Package " + pname + ": " + l)
+		}
+
+		if l == "" {
+			lines = append(lines, "//")
+		} else {
+			lines = append(lines, "// "+l)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 type ScopeReturn int
 
 const (
@@ -139,10 +277,21 @@ func (r ScopeReturn) String() string {
 }
 
 type Scope struct {
-	level   int // nesting level
-	vars    map[string]struct{}
-	imports map[string]string
-	main    bool
+	level     int // nesting level
+	vars      map[string]struct{}
+	types     map[string]string // python type of known names (i.e. "list", "str")
+	funcs     map[string]*ast.FunctionDef
+	classes   map[string]*ast.ClassDef
+	imports   map[string]string // the imported names and their module path
+	members   map[string]string // the names imported from a module (from module import member) and their member
+	main      bool
+	mainBody  *jen.Statement            // the body of the main function, for multiple __main__ guards
+	initBody  *jen.Statement            // the body of the init (or main) function, for the top level statements
+	script    bool                      // the top level statements go in main (there is no __main__ guard)
+	globals   []ast.Identifier          // the names declared global (in the top scope)
+	module    []ast.Stmt                // the module statements (in the top scope)
+	builders  map[ast.Identifier]string // string accumulators in a loop and their strings.Builder
+	nbuilders int                       // the strings.Builder declared in this scope, for unique names
 
 	file *jen.File
 
@@ -151,19 +300,35 @@ type Scope struct {
 	methods []*jen.Statement
 
 	returnType ScopeReturn
+	generator  bool // parsing the body of a generator function
+	arity      int  // the number of values returned by the function (see returnArity)
 
 	next *Scope
 	prev *Scope
 }
 
 func NewScope(f *jen.File, imp ...map[string]string) *Scope {
-	scope := &Scope{vars: make(map[string]struct{}), parsed: jen.Null(), file: f}
+	scope := &Scope{
+		vars:    make(map[string]struct{}),
+		types:   make(map[string]string),
+		funcs:   make(map[string]*ast.FunctionDef),
+		classes: make(map[string]*ast.ClassDef),
+		parsed:  jen.Null(),
+		file:    f,
+	}
+
 	if len(imp) > 0 {
 		scope.imports = imp[0]
 	} else {
 		scope.imports = make(map[string]string)
 	}
 
+	if len(imp) > 1 {
+		scope.members = imp[1]
+	} else {
+		scope.members = make(map[string]string)
+	}
+
 	return scope
 }
 
@@ -188,9 +353,11 @@ func (s *Scope) Top() bool {
 }
 
 func (s *Scope) Push() *Scope {
-	s.next = NewScope(s.file, s.imports)
+	s.next = NewScope(s.file, s.imports, s.members)
 	s.next.prev = s
 	s.next.level = s.level + 1
+	s.next.generator = s.generator
+	s.next.arity = s.arity
 	if verbose {
 		log.Println("PUSH", s.next.level)
 	}
@@ -265,926 +432,4469 @@ func (s *Scope) addName(id ast.Identifier) {
 	s.vars[string(id)] = struct{}{}
 }
 
-func (s *Scope) goBoolOp(op ast.BoolOpNumber) *jen.Statement {
-	switch op {
-	case ast.And:
-		return jen.Op("&&")
-
-	case ast.Or:
-		return jen.Op("||")
+// return the top (module) scope
+func (s *Scope) topScope() *Scope {
+	for s.prev != nil {
+		s = s.prev
 	}
 
-	return unknown("BOOLOP", op.String())
+	return s
 }
 
-func (s *Scope) goUnary(op ast.UnaryOpNumber) *jen.Statement {
-	switch op {
-	case ast.Not:
-		return jen.Op("!")
-
-	case ast.UAdd:
-		return jen.Op("+")
+// the package variables for the names declared global but never assigned at the top level
+func (s *Scope) goGlobals() *jen.Statement {
+	var vars []jen.Code
 
-	case ast.USub:
-		return jen.Op("-")
+	for _, name := range s.globals {
+		if _, ok := s.vars[string(name)]; !ok {
+			s.addName(name)
+			vars = append(vars, goVarId(name).Add(goAny))
+		}
 	}
 
-	return unknown("UNARY", op.String())
-}
+	switch len(vars) {
+	case 0:
+		return nil
+	case 1:
+		return jen.Var().Add(vars[0])
+	}
 
-func (s *Scope) goOp(op ast.OperatorNumber) *jen.Statement {
-	return s.goOpExt(op, "")
+	return jen.Var().Defs(vars...)
 }
 
-func (s *Scope) goOpExt(op ast.OperatorNumber, ext string) *jen.Statement {
-	switch op {
-	case ast.Add:
-		return jen.Op("+" + ext)
-	case ast.Sub:
-		return jen.Op("-" + ext)
-	case ast.Mult:
-		return jen.Op("*" + ext)
-	case ast.Div:
-		return jen.Op("/" + ext)
-	case ast.Modulo:
-		return jen.Op("%" + ext)
-	case ast.Pow:
-		return jen.Op("**" + ext)
-	case ast.LShift:
-		return jen.Op("<<" + ext)
-	case ast.RShift:
-		return jen.Op(">>" + ext)
-	case ast.BitOr:
-		return jen.Op("|" + ext)
-	case ast.BitXor:
-		return jen.Op("^" + ext)
-	case ast.BitAnd:
-		return jen.Op("&" + ext)
-	case ast.FloorDiv:
-		return jen.Op("/ /*floor*/" + ext)
+// check if the name is defined in any scope
+func (s *Scope) isDefined(id ast.Identifier) bool {
+	for curr := s; curr != nil; curr = curr.prev {
+		if _, ok := curr.vars[string(id)]; ok {
+			return true
+		}
 	}
 
-	return unknown("OP", op.String()+ext)
+	return false
 }
 
-func (s *Scope) goCmpOp(op ast.CmpOp) *jen.Statement {
-	switch op {
-	case ast.Eq:
-		return jen.Op("==")
-	case ast.NotEq:
-		return jen.Op("!=")
-	case ast.Lt:
-		return jen.Op("<")
-	case ast.LtE:
-		return jen.Op("<=")
-	case ast.Gt:
-		return jen.Op(">")
-	case ast.GtE:
-		return jen.Op(">=")
-	case ast.Is:
-		return jen.Op("==") // is
-	case ast.IsNot:
-		return jen.Op("!=") // is not
-	case ast.In:
-		return jen.Op("in")
-	case ast.NotIn:
-		return jen.Op("not in")
+// return the strings.Builder used for the string accumulator, if any
+func (s *Scope) builder(id ast.Identifier) string {
+	for curr := s; curr != nil; curr = curr.prev {
+		if b, ok := curr.builders[id]; ok {
+			return b
+		}
 	}
 
-	return unknown("CMPOP", op.String())
+	return ""
 }
 
-func (s *Scope) goSlice(name ast.Expr, value ast.Slicer) *jen.Statement {
-	stmt := s.goExpr(name)
-	start := jen.Empty()
-	end := jen.Empty()
+// find the string accumulators in a loop (s += v, with s a string not otherwise used in the loop)
+// and declare a strings.Builder for each of them. Returns the accumulator names.
+//
+// This is a heuristic (i.e. it doesn't check for a return in the loop) so it's only enabled with -builder.
+func (s *Scope) startBuilders(loop ast.Stmt, body []ast.Stmt) (names []ast.Identifier) {
+	if !useBuilder {
+		return nil
+	}
 
-	exprval := func(name, val ast.Expr) *jen.Statement {
-		if unary, ok := val.(*ast.UnaryOp); ok && unary.Op == ast.USub { // -x
-			return jen.Len(s.goExpr(name)).Op("-").Add(s.goExpr(unary.Operand))
-		} else {
-			return s.goExpr(val)
+	counts := map[ast.Identifier]int{}
+
+	walkBody(body, func(stmt ast.Stmt) {
+		if a, ok := stmt.(*ast.AugAssign); ok && a.Op == ast.Add {
+			if name, ok := a.Target.(*ast.Name); ok && s.nameType(name.Id) == "str" && s.builder(name.Id) == "" {
+				if counts[name.Id] == 0 {
+					names = append(names, name.Id)
+				}
+				counts[name.Id]++
+			}
 		}
+	})
+
+	if len(names) == 0 {
+		return nil
 	}
 
-	switch sl := value.(type) {
-	case *ast.Slice:
-		if sl.Lower != nil {
-			start = exprval(name, sl.Lower)
+	// all the references to the accumulator should be the s += v targets
+	ast.Walk(loop, func(node ast.Ast) bool {
+		if name, ok := node.(*ast.Name); ok {
+			if _, ok := counts[name.Id]; ok {
+				counts[name.Id]--
+			}
 		}
-		if sl.Upper != nil {
-			end = exprval(name, sl.Upper)
+		return true
+	})
+
+	accumulators := names[:0]
+
+	for _, id := range names {
+		if counts[id] != 0 {
+			continue
 		}
-		if sl.Step != nil {
-			// if sl.Lower==nil && sl.Upper==nil && sl.Step == -1
-			// it would be a reverse slice, not that we can easily do it
 
-			log.Printf("at %v:%v", value.GetLineno(), value.GetColOffset())
-			panic("step index not implemented")
+		if s.builders == nil {
+			s.builders = make(map[ast.Identifier]string)
 		}
-		stmt.Add(jen.Index(start, end))
 
-	case *ast.Index:
-		stmt.Add(jen.Index(exprval(name, sl.Value)))
+		b := string(id) + "Builder"
+		if s.nbuilders++; s.nbuilders > 1 { // a previous loop in the same block
+			b += strconv.Itoa(s.nbuilders)
+		}
 
-	case *ast.ExtSlice: // start:stop:step
-		log.Printf("at %v:%v", value.GetLineno(), value.GetColOffset())
-		panic("ExtSlice not implemented")
+		s.builders[id] = b
+		s.Add(jen.Var().Id(b).Qual("strings", "Builder"))
+		s.Add(jen.Id(b).Dot("WriteString").Call(goVarId(id)))
+		accumulators = append(accumulators, id)
 	}
 
-	return stmt
-}
-
-func (s *Scope) goIdentifiers(l []ast.Identifier) *jen.Statement {
-	return jen.ListFunc(func(g *jen.Group) {
-		for _, i := range l {
-			g.Add(goId(i))
-		}
-	})
+	return accumulators
 }
 
-func (s *Scope) strIdentifiers(l []ast.Identifier) string {
-	var sx []string
-	for _, i := range l {
-		sx = append(sx, string(i))
+// assign the content of the strings.Builder to the accumulators, at the end of the loop
+func (s *Scope) endBuilders(names []ast.Identifier) {
+	for _, id := range names {
+		s.Add(goVarId(id).Op("=").Id(s.builders[id]).Dot("String").Call())
+		delete(s.builders, id)
 	}
-
-	return strings.Join(sx, ",")
 }
 
-func (s *Scope) goInitialized(otype *jen.Statement, values []ast.Expr) *jen.Statement {
-	return jen.Parens(otype.Clone().ValuesFunc(func(g *jen.Group) {
-		for _, v := range values {
-			g.Add(s.goExpr(v))
-		}
-	}))
+// set the (python) type of a name in the current scope.
+// An empty type marks the name as untyped, hiding the type of the same name in an outer scope
+// (or the type of a previous assignment)
+func (s *Scope) setType(id ast.Identifier, typ string) {
+	s.types[string(id)] = typ
 }
 
-func (s *Scope) goExprList(values []ast.Expr) *jen.Statement {
-	return jen.ListFunc(func(g *jen.Group) {
-		for _, v := range values {
-			g.Add(s.goExpr(v))
+// mark a reassigned name as untyped, in the scope that defines its type
+// (a reassignment in a nested block changes the type of the outer variable)
+func (s *Scope) clearType(id ast.Identifier) {
+	for curr := s; curr != nil; curr = curr.prev {
+		if _, ok := curr.types[string(id)]; ok {
+			curr.types[string(id)] = ""
+			return
 		}
-	})
+	}
 }
 
-func (s *Scope) strExprList(values []ast.Expr) string {
-	var sx []string
-	for _, v := range values {
-		sx = append(sx, s.goExpr(v).GoString())
+// return the (python) type of a name, looking in all scopes
+func (s *Scope) nameType(id ast.Identifier) string {
+	for curr := s; curr != nil; curr = curr.prev {
+		if t, ok := curr.types[string(id)]; ok {
+			return t
+		}
 	}
 
-	return strings.Join(sx, ",")
+	return ""
 }
 
-func (s *Scope) goExprOrList(expr ast.Expr) *jen.Statement {
-	if tuple, ok := expr.(*ast.Tuple); ok {
-		return s.goExpr(tuple.Elts)
-	}
-	return s.goExpr(expr)
-}
+// return the full python name of an imported (dotted) name (i.e. path.join -> os.path.join), or ""
+func (s *Scope) importPath(name string) string {
+	parts := strings.SplitN(name, ".", 2)
 
-func lenExpr(expr ast.Expr) int {
-	if tuple, ok := expr.(*ast.Tuple); ok {
-		return len(tuple.Elts)
+	imp, ok := s.imports[parts[0]]
+	if !ok {
+		return ""
 	}
 
-	return 1
-}
+	if member, ok := s.members[parts[0]]; ok {
+		imp += "." + member
+	}
 
-func isNone(expr ast.Expr) bool {
-	if c, ok := expr.(*ast.NameConstant); ok {
-		return c.Value == py.None
+	if len(parts) > 1 {
+		imp += "." + parts[1]
 	}
 
-	return false
+	return imp
 }
 
-func isTuple(expr ast.Expr) bool {
-	_, ok := expr.(*ast.Tuple)
-	return ok
-}
+// return the Go equivalent of a (dotted) name from an imported module (i.e. os.path.join -> filepath.Join), or nil
+func (s *Scope) goSymbol(name string) *jen.Statement {
+	if sym, ok := gosymbols[s.importPath(name)]; ok {
+		return jen.Qual(sym[0], sym[1])
+	}
 
-func isList(expr ast.Expr) bool {
-	_, ok := expr.(*ast.List)
-	return ok
+	return nil
 }
 
-// check for `__name__ == "__main__"`
-func isNameMain(expr ast.Expr) bool {
-	comp, ok := expr.(*ast.Compare)
+// return the class definition for a nested class (Outer.Inner or self.Inner), if known
+func (s *Scope) nestedClass(attr *ast.Attribute) *ast.ClassDef {
+	name, ok := attr.Value.(*ast.Name)
 	if !ok {
-		return false
-	}
-	if len(comp.Ops) != 1 {
-		return false
-	}
-	if comp.Ops[0] != ast.Eq {
-		return false
+		return nil
 	}
 
-	name, ok := comp.Left.(*ast.Name)
-	if !ok {
-		return false
+	outer := string(name.Id)
+	if s.classDef(name.Id) == nil {
+		outer = s.exprType(name) // an instance of the outer class
 	}
-	if string(name.Id) != "__name__" {
-		return false
+
+	if outer == "" {
+		return nil
 	}
 
-	str, ok := comp.Comparators[0].(*ast.Str)
-	if !ok {
-		return false
+	return s.classDef(ast.Identifier(outer + "." + string(attr.Attr)))
+}
+
+// return the full python name of an imported (dotted) name (i.e. suppress -> contextlib.suppress)
+func (s *Scope) importedName(expr ast.Expr) string {
+	var name string
+
+	switch v := expr.(type) {
+	case *ast.Name:
+		name = string(v.Id)
+
+	case *ast.Attribute:
+		x, b, a := strAttribute(v)
+		if x != nil {
+			return ""
+		}
+
+		name = b + "." + a
+
+	default:
+		return ""
 	}
-	if string(str.S) != "__main__" {
-		return false
+
+	return s.importPath(name)
+}
+
+// return the definition of a function, looking in all scopes
+func (s *Scope) funcDef(id ast.Identifier) *ast.FunctionDef {
+	for curr := s; curr != nil; curr = curr.prev {
+		if f, ok := curr.funcs[string(id)]; ok {
+			return f
+		}
 	}
 
-	return true
+	return nil
 }
 
-func exprIds(expr ast.Expr) (ids []ast.Identifier) {
-	if tuple, ok := expr.(*ast.Tuple); ok {
-		for _, x := range tuple.Elts {
-			ids = append(ids, x.(*ast.Name).Id)
+// return the definition of a class, looking in all scopes
+func (s *Scope) classDef(id ast.Identifier) *ast.ClassDef {
+	for curr := s; curr != nil; curr = curr.prev {
+		if c, ok := curr.classes[string(id)]; ok {
+			return c
 		}
-	} else {
-		ids = append(ids, expr.(*ast.Name).Id)
 	}
 
-	return
+	return nil
 }
 
-func (s *Scope) gomprehension(c ast.Comprehension) (*jen.Statement, *jen.Statement) {
-	iter, _ := s.goFor(c.Target, c.Iter)
-	cond := iter
-	if len(c.Ifs) > 0 {
-		ccond := s.goExpr(c.Ifs[0])
-		for _, c := range c.Ifs[1:] {
-			ccond.Add(jen.Op("&&"))
-			ccond.Add(s.goExpr(c))
+// return the class method with the specified name, if defined
+func classMethod(cls *ast.ClassDef, name string) *ast.FunctionDef {
+	for _, stmt := range cls.Body {
+		if f, ok := stmt.(*ast.FunctionDef); ok && string(f.Name) == name {
+			return f
 		}
-		cond = jen.If(ccond)
-		iter.Block(cond)
 	}
 
-	return iter, cond
+	return nil
 }
 
-// print k=v either for function definitions (def=true) or for function call (def=false)
-func (s *Scope) goKvals(kk []*ast.Keyword, def bool) *jen.Statement {
-	return jen.ListFunc(func(g *jen.Group) {
-		if def {
-			for _, k := range kk {
-				g.Add(goId(k.Arg).Commentf("/*=%v*/", s.goExpr(k.Value).GoString()))
-			}
-		} else {
-			for _, k := range kk {
-				g.Commentf("/*%v=*/", string(k.Arg)).Add(s.goExpr(k.Value))
-			}
+// return true if the expression is an instance of a known class that defines the specified method
+func (s *Scope) hasMethod(expr ast.Expr, name string) bool {
+	etype := s.exprType(expr)
+	if etype == "defaultdict" { // runtime.DefaultDict
+		switch name {
+		case "__getitem__", "__setitem__", "__len__":
+			return true
 		}
-	})
-}
 
-func (s *Scope) goExpr(expr interface{}) *jen.Statement {
-	if verbose {
-		log.Printf("XXX %T %#v\n\n", expr, expr)
+		return false
 	}
 
-	switch v := expr.(type) {
-	case []ast.Expr:
-		return s.goExprList(v)
+	cls := s.classDef(ast.Identifier(etype))
+	if cls == nil {
+		return false
+	}
 
-	case []*ast.Keyword:
-		return jen.ListFunc(func(g *jen.Group) {
-			for _, k := range v {
-				g.Add(goId(k.Arg).Commentf("/*=%v*/", s.goExpr(k.Value).GoString()))
-			}
-		})
+	if name == "__eq__" && isDataclass(cls) { // generated by goDataclassMethods
+		return true
+	}
 
-	case *ast.Tuple:
-		return s.goInitialized(goTuple, v.Elts)
+	return classMethod(cls, name) != nil
+}
 
-	case *ast.List:
-		return s.goInitialized(goList, v.Elts)
+// return the getter and setter for obj.x, if obj is an instance of a known class and x one of its properties
+func (s *Scope) attrProperty(attr *ast.Attribute) (getter, setter *ast.FunctionDef) {
+	if cls := s.classDef(ast.Identifier(s.exprType(attr.Value))); cls != nil {
+		return classProperty(cls, string(attr.Attr))
+	}
 
-	case *ast.Dict:
-		return jen.Parens(goDict.Clone().Values(jen.DictFunc(func(d jen.Dict) {
-			for i, k := range v.Keys {
-				d[s.goExpr(k)] = s.goExpr(v.Values[i])
-			}
-		})))
+	return nil, nil
+}
 
-	case *ast.Num:
-		switch n := v.N.(type) {
-		case py.Int:
-			return jen.Lit(int(n))
+// return the (python) type of an attribute, if it's a known class variable
+func (s *Scope) attrType(attr *ast.Attribute) string {
+	otype := s.exprType(attr.Value)
+	if otype == "" {
+		return ""
+	}
 
-		case py.Float:
-			return jen.Lit(float64(n))
+	cls := s.classDef(ast.Identifier(otype))
+	if cls == nil {
+		return ""
+	}
 
-		case py.Complex:
-			return jen.Lit(complex128(n))
+	for _, stmt := range cls.Body {
+		if a, ok := stmt.(*ast.Assign); ok {
+			for _, t := range a.Targets {
+				if n, ok := t.(*ast.Name); ok && n.Id == attr.Attr {
+					return s.exprType(a.Value)
+				}
+			}
+		}
+	}
 
-		default:
-			log.Printf("number %v at %v:%v", v.N, v.GetLineno(), v.GetColOffset())
-			panic("invalid number")
+	// an instance attribute, with the type of its field (see goValueType)
+	names, values := instanceAttributes(cls)
+	for i, name := range names {
+		if name == attr.Attr && values[i] != nil {
+			return valueType(values[i])
 		}
+	}
 
-	case ast.Identifier:
-		return goId(v)
+	return ""
+}
 
-	case *ast.NameConstant:
-		switch v.Value {
-		case py.None:
-			return jen.Nil()
+// the type of the values returned by the itertools functions
+var itertoolsTypes = map[string]string{
+	"itertools.chain":  "list",
+	"itertools.repeat": "list",
+	"itertools.count":  "chan",
+}
 
-		case py.True:
-			return jen.True()
+// return the (python) type of an expression, if known
+func (s *Scope) exprType(expr ast.Expr) string {
+	switch v := expr.(type) {
+	case *ast.Name:
+		return s.nameType(v.Id)
 
-		case py.False:
-			return jen.False()
+	case *ast.List, *ast.ListComp:
+		return "list"
 
-		default:
-			s, _ := py.Str(v.Value)
-			return jen.Lit(string(s.(py.String)))
-		}
+	case *ast.Tuple:
+		return "tuple"
+
+	case *ast.Dict, *ast.DictComp:
+		return "dict"
+
+	case *ast.Set, *ast.SetComp:
+		return "set"
+
+	case *ast.GeneratorExp:
+		return "chan"
 
 	case *ast.Str:
-		return jen.Lit(string(v.S))
+		return "str"
 
-	case *ast.UnaryOp:
-		if v.Op == ast.Invert {
-			return jen.Op("-").Parens(s.goExpr(v.Operand).Op("+").Lit(1))
-		} else {
-			return s.goUnary(v.Op).Add(s.goExpr(v.Operand))
+	case *ast.Bytes:
+		return "bytes"
+
+	case *ast.BinOp:
+		if _, ok := v.Left.(*ast.Str); ok && v.Op == ast.Modulo { // "format" % args
+			return "str"
 		}
 
-	case *ast.BoolOp:
-		stmt := s.goExpr(v.Values[0])
-		for _, x := range v.Values[1:] {
-			stmt.Add(s.goBoolOp(v.Op))
-			stmt.Add(s.goExpr(x))
+		if s.exprType(v.Left) == "complex" || s.exprType(v.Right) == "complex" {
+			return "complex"
 		}
-		return stmt
 
-	case *ast.BinOp:
-		if v.Op == ast.Modulo { // %
-			if _, ok := v.Left.(*ast.Str); ok { // this is really a formatting operation
-				printfunc := jen.Qual("fmt", "Sprintf")
-				printfmt := s.goExpr(v.Left)
-				params := s.goExpr(v.Right)
-				if tuple, ok := v.Right.(*ast.Tuple); ok {
-					params = s.goExprList(tuple.Elts)
-				}
-				return printfunc.Params(printfmt, params)
-			}
+	case *ast.Num:
+		switch v.N.(type) {
+		case py.Int:
+			return "int"
+		case py.Float:
+			return "float"
+		case py.Complex:
+			return "complex"
 		}
 
-		if v.Op == ast.Pow { // **
-			return jen.Qual("math", "Pow").Params(s.goExpr(v.Left), s.goExpr(v.Right))
+	case *ast.NameConstant:
+		if v.Value == py.None {
+			return "None"
 		}
+		return "bool"
 
-		return s.goExpr(v.Left).Add(s.goOp(v.Op)).Add(s.goExpr(v.Right))
+	case *ast.Attribute:
+		if (v.Attr == "real" || v.Attr == "imag") && s.exprType(v.Value) == "complex" {
+			return "float"
+		}
+
+		return s.attrType(v)
 
 	case *ast.Compare:
-		stmt := jen.Null()
+		return "bool"
 
-		left := s.goExpr(v.Left)
-		right := (*jen.Statement)(nil)
+	case *ast.UnaryOp:
+		if v.Op == ast.Not {
+			return "bool"
+		}
+		return s.exprType(v.Operand)
 
-		for i, op := range v.Ops {
-			if right != nil {
-				stmt.Op("&&")
-				left = right.Clone()
+	case *ast.BoolOp:
+		for _, x := range v.Values {
+			if s.exprType(x) != "bool" {
+				return ""
 			}
+		}
+		return "bool"
 
-			right = s.goExpr(v.Comparators[i])
+	case *ast.Call:
+		if isDefaultDict(v) {
+			return "defaultdict"
+		}
 
-			if op == ast.In {
-				stmt.Add(goContains.Clone().Call(right, left))
-			} else if op == ast.NotIn {
-				stmt.Op("!").Add(goContains.Clone().Call(right, left))
-			} else {
-				stmt.Add(left)
-				stmt.Add(s.goCmpOp(op))
-				stmt.Add(right)
-			}
+		if t, ok := itertoolsTypes[s.importedName(v.Func)]; ok {
+			return t
 		}
 
-		return stmt
+		switch f := v.Func.(type) {
+		case *ast.Name:
+			switch string(f.Id) {
+			case "len", "int", "ord":
+				return "int"
+			case "str", "repr", "chr", "format":
+				return "str"
+			case "float":
+				return "float"
+			case "complex":
+				return "complex"
+			case "abs":
+				if len(v.Args) == 1 {
+					if t := s.exprType(v.Args[0]); t != "complex" {
+						return t
+					}
+					return "float"
+				}
+			case "bool", "isinstance", "callable", "hasattr", "all", "any":
+				return "bool"
+			case "list", "sorted":
+				return "list"
+			case "open":
+				return "file"
+			case "dict":
+				return "dict"
+			case "tuple":
+				return "tuple"
+			case "set":
+				return "set"
+			case "bytes", "bytearray":
+				return "bytes"
+			}
 
-	case *ast.Name:
-		return goId(v.Id)
+			if s.classDef(f.Id) != nil {
+				return string(f.Id)
+			}
 
-	case *ast.Attribute:
-		x, b, a := strAttribute(v)
-		a = rename(a)
+			if s.callArity(v) > 1 {
+				return "tuple"
+			}
 
-		if x != nil {
-			return s.goExpr(x).Dot(a)
+			if f := s.calledFunc(v); f != nil && isGenerator(f.Body) {
+				return "chan"
+			}
+
+		case *ast.Attribute:
+			if cls := s.nestedClass(f); cls != nil {
+				return string(cls.Name)
+			}
+
+			switch string(f.Attr) {
+			case "startswith", "endswith", "isspace", "isalpha", "isdigit", "isnumeric", "isupper", "islower":
+				return "bool"
+			case "upper", "lower", "strip", "lstrip", "rstrip", "replace", "join",
+				"zfill", "center", "ljust", "rjust", "format", "format_map":
+				return "str" // so that the string methods can be chained
+			case "find", "rfind":
+				return "int"
+			case "conjugate":
+				return s.exprType(f.Value)
+			case "split", "splitlines", "keys", "values", "items":
+				return "list"
+			case "partition", "rpartition":
+				return "tuple"
+			}
 		}
+	}
 
-		switch {
-		case b == "re" && a == "compile":
-			return jen.Qual("regexp", "MustCompile")
+	return ""
+}
 
-		case b == "re" && a == "match":
-			return jen.Qual("regexp", "MatchString")
+func (s *Scope) goBoolOp(op ast.BoolOpNumber) *jen.Statement {
+	switch op {
+	case ast.And:
+		return jen.Op("&&")
 
-		case b == "sys" && a == "argv":
-			return jen.Qual("os", "Args")
+	case ast.Or:
+		return jen.Op("||")
+	}
 
-		case b == "sys" && a == "stdin":
-			return jen.Qual("os", "Stdin")
+	return unknown("BOOLOP", op.String())
+}
 
-		case b == "sys" && a == "stdout":
-			return jen.Qual("os", "Stdout")
+// convert an expression used as a condition, applying Python truthiness rules
+func (s *Scope) goCond(expr ast.Expr) *jen.Statement {
+	switch v := expr.(type) {
+	case *ast.UnaryOp:
+		if v.Op == ast.Not {
+			return s.goNot(v.Operand)
+		}
 
-		case b == "sys" && a == "stderr":
-			return jen.Qual("os", "Stderr")
+	case *ast.BoolOp:
+		stmt := s.goCond(v.Values[0])
+		for _, x := range v.Values[1:] {
+			stmt.Add(s.goBoolOp(v.Op))
+			stmt.Add(s.goCond(x))
+		}
+		return stmt
+	}
 
-		case b == "sys.stdin":
-			return jen.Qual("os", "Stdin").Dot(a)
+	switch s.exprType(expr) {
+	case "bool":
+		return s.goExpr(expr)
 
-		case b == "sys.stdout":
-			return jen.Qual("os", "Stdout").Dot(a)
+	case "list", "tuple", "dict", "set", "str":
+		return jen.Len(s.goExpr(expr)).Op(">").Lit(0)
 
-		case b == "sys.stderr":
-			return jen.Qual("os", "Stderr").Dot(a)
-		}
+	case "int", "float", "complex":
+		return s.goExpr(expr).Op("!=").Lit(0)
 
-		if imp, ok := s.imports[b]; ok {
-			return jen.Qual(imp, a)
+	case "None":
+		if isNone(expr) { // a name could be reassigned in a nested block, keeping the None type
+			return jen.False()
 		}
+	}
 
-		return jen.Id(b).Dot(a)
+	return jen.Qual(goRuntime, "Truthy").Call(s.goExpr(expr))
+}
 
-	case *ast.Subscript:
-		return s.goSlice(v.Value, v.Slice)
+// the truth value of a literal (number, string, True/False/None)
+func literalTruth(expr ast.Expr) (bool, bool) {
+	switch v := expr.(type) {
+	case *ast.Num:
+		switch n := v.N.(type) {
+		case py.Int:
+			return n != 0, true
+		case py.Float:
+			return n != 0, true
+		}
 
-	case *ast.Call:
-		return s.goCall(v)
+	case *ast.Str:
+		return len(v.S) > 0, true
 
-	case *ast.Lambda:
-		args, _ := s.goFunctionArguments(v.Args, false)
-		return jen.Func().Params(args).Block(s.goExpr(v.Body)).Call()
+	case *ast.NameConstant:
+		return v.Value == py.True, true
+	}
 
-	case *ast.IfExp:
-		return jen.Func().Params().Block(
-			jen.If(s.goExpr(v.Test)).
-				Block(jen.Return(s.goExpr(v.Body))).
-				Else().
-				Block(jen.Return(s.goExpr(v.Orelse)))).Call()
+	return false, false
+}
 
-	case *ast.ListComp:
-		outer, inner := s.gomprehension(v.Generators[0])
-		for _, g := range v.Generators[1:] {
-			outer1, inner1 := s.gomprehension(g)
-			inner.Add(jen.Block(outer1))
-			inner = inner1
+// convert `not expr`, applying Python truthiness rules
+func (s *Scope) goNot(expr ast.Expr) *jen.Statement {
+	switch s.exprType(expr) {
+	case "bool":
+		switch expr.(type) {
+		case *ast.Compare, *ast.BoolOp:
+			return jen.Op("!").Parens(s.goExpr(expr))
 		}
-		inner.Add(jen.Block(jen.Id("lc").Op("=").Append(jen.Id("lc"), s.goExpr(v.Elt))))
-		return jen.Func().Params().Params(jen.Id("lc").Add(goList)).Block(outer, jen.Return(jen.Id("lc"))).Call()
+		return jen.Op("!").Add(s.goExpr(expr))
 
-	case *ast.DictComp:
-		outer, inner := s.gomprehension(v.Generators[0])
-		for _, g := range v.Generators[1:] {
-			outer1, inner1 := s.gomprehension(g)
-			inner.Add(jen.Block(outer1))
-			inner = inner1
-		}
-		inner.Add(jen.Block(jen.Id("mm").Index(s.goExpr(v.Key)).Op("=").Add(s.goExpr(v.Value))))
-		return jen.Func().Params().Params(jen.Id("mm").Add(goDict)).Block(
-			jen.Id("mm").Op("=").Add(goDict).Values(),
-			outer,
-			jen.Return()).Call()
+	case "list", "tuple", "dict", "set", "str":
+		return jen.Len(s.goExpr(expr)).Op("==").Lit(0)
 
-	case *ast.GeneratorExp:
-		outer, inner := s.gomprehension(v.Generators[0])
-		for _, g := range v.Generators[1:] {
-			outer1, inner1 := s.gomprehension(g)
-			inner.Add(jen.Block(outer1))
-			inner = inner1
+	case "int", "float", "complex":
+		return s.goExpr(expr).Op("==").Lit(0)
+
+	case "None":
+		if isNone(expr) {
+			return jen.True()
 		}
-		inner.Add(jen.Block(jen.Id("c").Op("<-").Add(s.goExpr(v.Elt))))
-		return jen.Func().Params().Params(jen.Id("c").Chan().Add(goAny)).Block(
-			jen.Id("c").Op("=").Make(jen.Chan().Add(goAny)),
-			jen.Go().Func().Params().Block(outer, jen.Close(jen.Id("c"))).Call(),
-			jen.Return(),
-		).Call()
 	}
 
-	return unknown("EXPR", expr)
+	return jen.Op("!").Qual(goRuntime, "Truthy").Call(s.goExpr(expr))
 }
 
-func goId(id ast.Identifier) *jen.Statement {
-	return jen.Id(rename(string(id)))
-}
+func (s *Scope) goUnary(op ast.UnaryOpNumber) *jen.Statement {
+	switch op {
+	case ast.Not:
+		return jen.Op("!")
 
-func (s *Scope) goFunctionArguments(args *ast.Arguments, skipReceiver bool) (*jen.Statement, *ast.Arg) {
-	var recv *ast.Arg
+	case ast.UAdd:
+		return jen.Op("+")
 
-	if args == nil {
-		return jen.Null(), recv
+	case ast.USub:
+		return jen.Op("-")
 	}
 
-	var params []jen.Code
-
-	aargs := args.Args
-	if skipReceiver && len(aargs) > 0 {
-		recv, aargs = aargs[0], aargs[1:]
-	}
+	return unknown("UNARY", op.String())
+}
 
-	for _, arg := range aargs {
+func (s *Scope) goOp(op ast.OperatorNumber) *jen.Statement {
+	return s.goOpExt(op, "")
+}
+
+func (s *Scope) goOpExt(op ast.OperatorNumber, ext string) *jen.Statement {
+	switch op {
+	case ast.Add:
+		return jen.Op("+" + ext)
+	case ast.Sub:
+		return jen.Op("-" + ext)
+	case ast.Mult:
+		return jen.Op("*" + ext)
+	case ast.Div:
+		return jen.Op("/" + ext)
+	case ast.Modulo:
+		return jen.Op("%" + ext)
+	case ast.Pow:
+		return jen.Op("**" + ext)
+	case ast.LShift:
+		return jen.Op("<<" + ext)
+	case ast.RShift:
+		return jen.Op(">>" + ext)
+	case ast.BitOr:
+		return jen.Op("|" + ext)
+	case ast.BitXor:
+		return jen.Op("^" + ext)
+	case ast.BitAnd:
+		return jen.Op("&" + ext)
+	case ast.FloorDiv:
+		return jen.Op("/ /*floor*/" + ext)
+	}
+
+	return unknown("OP", op.String()+ext)
+}
+
+// the runtime function implementing an arithmetic operation on Any values
+func runtimeOp(op ast.OperatorNumber) string {
+	switch op {
+	case ast.Add:
+		return "Add"
+	case ast.Sub:
+		return "Sub"
+	case ast.Mult:
+		return "Mul"
+	case ast.Div:
+		return "Div"
+	case ast.FloorDiv:
+		return "FloorDiv"
+	case ast.Modulo:
+		return "Mod"
+	}
+
+	return ""
+}
+
+func (s *Scope) goCmpOp(op ast.CmpOp) *jen.Statement {
+	switch op {
+	case ast.Eq:
+		return jen.Op("==")
+	case ast.NotEq:
+		return jen.Op("!=")
+	case ast.Lt:
+		return jen.Op("<")
+	case ast.LtE:
+		return jen.Op("<=")
+	case ast.Gt:
+		return jen.Op(">")
+	case ast.GtE:
+		return jen.Op(">=")
+	case ast.Is:
+		return jen.Op("==") // is
+	case ast.IsNot:
+		return jen.Op("!=") // is not
+	case ast.In:
+		return jen.Op("in")
+	case ast.NotIn:
+		return jen.Op("not in")
+	}
+
+	return unknown("CMPOP", op.String())
+}
+
+// an index value for name[val], where negative values are relative to the end
+func (s *Scope) goIndexValue(name, val ast.Expr) *jen.Statement {
+	if unary, ok := val.(*ast.UnaryOp); ok && unary.Op == ast.USub { // -x
+		return jen.Len(s.goExpr(name)).Op("-").Add(s.goExpr(unary.Operand))
+	} else {
+		return s.goExpr(val)
+	}
+}
+
+func (s *Scope) goSlice(name ast.Expr, value ast.Slicer) *jen.Statement {
+	if sl, ok := value.(*ast.Slice); ok && sl.Step == nil && s.exprType(name) == "str" {
+		return s.goStrSlice(name, sl)
+	}
+
+	stmt := s.goExpr(name)
+	start := jen.Empty()
+	end := jen.Empty()
+
+	switch sl := value.(type) {
+	case *ast.Slice:
+		if sl.Lower != nil {
+			start = s.goIndexValue(name, sl.Lower)
+		}
+		if sl.Upper != nil {
+			end = s.goIndexValue(name, sl.Upper)
+		}
+		if sl.Step != nil {
+			// if sl.Lower==nil && sl.Upper==nil && sl.Step == -1
+			// it would be a reverse slice, not that we can easily do it
+
+			log.Printf("at %v:%v", value.GetLineno(), value.GetColOffset())
+			panic("step index not implemented")
+		}
+		stmt.Add(jen.Index(start, end))
+
+	case *ast.Index:
+		if s.hasMethod(name, "__getitem__") { // obj[k] -> obj.Get(k)
+			return stmt.Dot("Get").Call(s.goExpr(sl.Value))
+		}
+
+		stmt.Add(jen.Index(s.goIndexValue(name, sl.Value)))
+
+	case *ast.ExtSlice: // start:stop:step
+		log.Printf("at %v:%v", value.GetLineno(), value.GetColOffset())
+		panic("ExtSlice not implemented")
+	}
+
+	return stmt
+}
+
+// s[lo:hi] -> runtime.StrSlice(s, lo, hi), on runes and with python negative bounds
+func (s *Scope) goStrSlice(name ast.Expr, sl *ast.Slice) *jen.Statement {
+	str := s.goExpr(name)
+
+	switch {
+	case sl.Lower == nil && sl.Upper == nil: // s[:]
+		return str
+
+	case sl.Upper == nil: // s[lo:]
+		return jen.Qual(goRuntime, "StrSlice").Call(str, s.goExpr(sl.Lower))
+
+	case sl.Lower == nil: // s[:hi]
+		return jen.Qual(goRuntime, "StrSlice").Call(str, jen.Lit(0), s.goExpr(sl.Upper))
+	}
+
+	return jen.Qual(goRuntime, "StrSlice").Call(str, s.goExpr(sl.Lower), s.goExpr(sl.Upper))
+}
+
+func isDefaultDict(call *ast.Call) bool {
+	if name, ok := call.Func.(*ast.Name); ok && name.Id == "defaultdict" {
+		return true
+	}
+
+	return isAttribute(call.Func, "collections", "defaultdict")
+}
+
+// the factory function for a defaultdict (i.e. int -> func() Any { return 0 })
+func (s *Scope) goDefaultFactory(factory ast.Expr) *jen.Statement {
+	var value *jen.Statement
+
+	switch f := factory.(type) {
+	case *ast.Lambda:
+		return s.goExpr(f)
+
+	case *ast.NameConstant:
+		if f.Value == py.None {
+			return jen.Nil()
+		}
+
+	case *ast.Name:
+		switch string(f.Id) {
+		case "int":
+			value = jen.Lit(0)
+		case "float":
+			value = jen.Lit(0.0)
+		case "str":
+			value = jen.Lit("")
+		case "bool":
+			value = jen.False()
+		case "list":
+			value = goList.Clone().Values()
+		case "dict":
+			value = goDict.Clone().Values()
+		}
+	}
+
+	if value == nil { // a function or class
+		value = s.goExpr(factory).Call()
+	}
+
+	return jen.Func().Params().Add(goAny).Block(jen.Return(value))
+}
+
+// a key function for sorted() (a lambda or a function reference, i.e. len or str.lower)
+func (s *Scope) goKeyFunc(key ast.Expr) *jen.Statement {
+	switch k := key.(type) {
+	case *ast.Name:
+		switch string(k.Id) {
+		case "len":
+			return jen.Qual(goRuntime, "Len")
+		case "str":
+			return jen.Qual(goRuntime, "Str")
+		}
+
+	case *ast.Attribute:
+		if name, ok := k.Value.(*ast.Name); ok && name.Id == "str" {
+			switch string(k.Attr) {
+			case "lower":
+				return jen.Qual("strings", "ToLower")
+			case "upper":
+				return jen.Qual("strings", "ToUpper")
+			case "strip":
+				return jen.Qual("strings", "TrimSpace")
+			}
+		}
+	}
+
+	return s.goExpr(key)
+}
+
+// a format spec: alignment, width, thousands separator, precision and type
+const formatSpec = `([<>])?(\d+)?(,)?(\.\d+)?([sdfegxXo%])?`
+
+var reFormatSpec = regexp.MustCompile(`^` + formatSpec + `$`)
+var reFormatField = regexp.MustCompile(`^(\w+)(?::` + formatSpec + `)?$`)
+
+// format(value, spec) -> fmt.Sprintf("%5.2f", value) or runtime.FormatThousands(value, prec),
+// where m are the format spec groups
+func goFormatSpec(value *jen.Statement, m []string) *jen.Statement {
+	align, width, comma, prec, verb := m[0], m[1], m[2], m[3], m[4]
+	if align == "<" {
+		width = "-" + width
+	}
+
+	if comma != "" { // format(n, ",.2f")
+		p := -1
+		if prec != "" {
+			p, _ = strconv.Atoi(prec[1:])
+		} else if verb == "f" {
+			p = 6
+		}
+
+		value = jen.Qual(goRuntime, "FormatThousands").Call(value, jen.Lit(p))
+		if width == "" {
+			return value
+		}
+
+		return jen.Qual("fmt", "Sprintf").Call(jen.Lit("%"+width+"s"), value)
+	}
+
+	switch verb {
+	case "%":
+		return nil
+
+	case "":
+		verb = "v"
+	}
+
+	return jen.Qual("fmt", "Sprintf").Call(jen.Lit("%"+width+prec+verb), value)
+}
+
+// convert a str.format style format string ("{name:>5}") to the equivalent "%(name)5s" format,
+// for the simple cases (named fields with alignment, width, precision and type)
+func percentFormat(format string) (string, bool) {
+	var res strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+
+		switch {
+		case c == '%':
+			res.WriteString("%%")
+
+		case (c == '{' || c == '}') && i+1 < len(format) && format[i+1] == c: // escaped {{ or }}
+			res.WriteByte(c)
+			i++
+
+		case c == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return "", false
+			}
+
+			m := reFormatField.FindStringSubmatch(format[i+1 : i+end])
+			if m == nil {
+				return "", false
+			}
+
+			verb := m[6]
+			if verb == "" && m[2] == "" && m[4] == "" {
+				verb = "v" // the default alignment depends on the value (strings are left aligned)
+			} else if verb == "" {
+				verb = "s"
+			}
+
+			res.WriteString("%(" + m[1] + ")" + m[4]) // the thousands separator is a runtime.FormatMap flag
+			if m[2] == "<" || (m[2] == "" && m[6] == "s") {
+				res.WriteString("-")
+			}
+			res.WriteString(m[3] + m[5] + verb)
+			i += end
+
+		case c == '}':
+			return "", false
+
+		default:
+			res.WriteByte(c)
+		}
+	}
+
+	return res.String(), true
+}
+
+// a str.format field: name, attribute and index path, conversion and format spec
+var reStrFormatField = regexp.MustCompile(`^(\w*)((?:\.\w+|\[\w+\])*)(?:!([rs]))?(?::` + formatSpec + `)?$`)
+var reStrFormatPath = regexp.MustCompile(`\.(\w+)|\[(\w+)\]`)
+
+// "{0.name} {data[0]:>5}".format(obj, data=l) -> fmt.Sprintf("%s %5v", obj.name, l[0]),
+// or runtime.Format(format, kwargs, args...) if the format can't be converted
+// (i.e. the format is not a literal, or a field path is on a value of unknown type)
+func (s *Scope) goStrFormat(format ast.Expr, call *ast.Call) *jen.Statement {
+	if str, ok := format.(*ast.Str); ok && call.Starargs == nil && call.Kwargs == nil {
+		if f := s.goSprintf(string(str.S), call); f != nil {
+			return f
+		}
+	}
+
+	args := []jen.Code{s.goExpr(format), s.goKwargs(call.Keywords, call.Kwargs)}
+
+	if call.Starargs != nil {
+		var values []jen.Code
+		for _, arg := range call.Args {
+			values = append(values, s.goExpr(arg))
+		}
+
+		args = append(args, jen.Append(goList.Clone().Values(values...), s.goExpr(call.Starargs).Op("...")).Op("..."))
+	} else {
+		for _, arg := range call.Args {
+			args = append(args, s.goExpr(arg))
+		}
+	}
+
+	return jen.Qual(goRuntime, "Format").Call(args...)
+}
+
+// convert a str.format call to fmt.Sprintf, or return nil if some of the fields can't be converted
+func (s *Scope) goSprintf(format string, call *ast.Call) *jen.Statement {
+	var res strings.Builder
+
+	params := []jen.Code{nil} // the format goes first
+	auto := 0                 // the next automatic field number
+
+	keywords := map[string]ast.Expr{}
+	for _, k := range call.Keywords {
+		keywords[string(k.Arg)] = k.Value
+	}
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+
+		switch {
+		case c == '%':
+			res.WriteString("%%")
+
+		case (c == '{' || c == '}') && i+1 < len(format) && format[i+1] == c: // escaped {{ or }}
+			res.WriteByte(c)
+			i++
+
+		case c == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				return nil
+			}
+
+			m := reStrFormatField.FindStringSubmatch(format[i+1 : i+end])
+			if m == nil {
+				return nil
+			}
+
+			var arg ast.Expr
+
+			if m[1] == "" {
+				if auto >= len(call.Args) {
+					return nil
+				}
+				arg = call.Args[auto]
+				auto++
+			} else if n, err := strconv.Atoi(m[1]); err == nil {
+				if n >= len(call.Args) {
+					return nil
+				}
+				arg = call.Args[n]
+			} else if arg = keywords[m[1]]; arg == nil {
+				return nil
+			}
+
+			if arg = s.formatPath(arg, m[2]); arg == nil {
+				return nil
+			}
+
+			conv, align, width, comma, prec, verb := m[3], m[4], m[5], m[6], m[7], m[8]
+			if comma != "" || verb == "%" {
+				return nil
+			}
+
+			value := s.goExpr(arg)
+			left := align == "<"
+
+			switch t := s.exprType(arg); {
+			case conv == "r" && (verb == "" || verb == "s"):
+				value, verb, left = jen.Qual(goRuntime, "Repr").Call(value), "s", align != ">"
+
+			case conv == "s" && (verb == "" || verb == "s"), verb == "s", verb == "" && t == "str":
+				if t != "str" || conv != "" {
+					value = jen.Qual(goRuntime, "Str").Call(value)
+				}
+				verb, left = "s", align != ">" // strings are left aligned
+
+			case conv != "":
+				return nil
+
+			case verb == "":
+				if t != "int" && t != "float" && width != "" { // the alignment depends on the type
+					return nil
+				}
+				verb = "v"
+
+			case strings.Contains("fFeEgG", verb) && t == "int":
+				value = jen.Float64().Call(value)
+			}
+
+			res.WriteByte('%')
+			if left && width != "" {
+				res.WriteByte('-')
+			}
+			res.WriteString(width + prec + verb)
+			params = append(params, value)
+			i += end
+
+		case c == '}':
+			return nil
+
+		default:
+			res.WriteByte(c)
+		}
+	}
+
+	params[0] = jen.Lit(res.String())
+	return jen.Qual("fmt", "Sprintf").Call(params...)
+}
+
+// the expression for a str.format field path (.name or [index]) on the argument,
+// or nil if the type of a value in the path is not known (so it can't be converted to a Go expression)
+func (s *Scope) formatPath(arg ast.Expr, path string) ast.Expr {
+	for _, m := range reStrFormatPath.FindAllStringSubmatch(path, -1) {
+		t := s.exprType(arg)
+
+		if m[1] != "" { // obj.name
+			if s.classDef(ast.Identifier(t)) == nil {
+				return nil
+			}
+
+			arg = &ast.Attribute{Value: arg, Attr: ast.Identifier(m[1]), Ctx: ast.Load}
+			continue
+		}
+
+		var index ast.Expr
+
+		switch n, err := strconv.Atoi(m[2]); {
+		case (t == "list" || t == "tuple") && err == nil: // l[0]
+			index = &ast.Num{N: py.Int(n)}
+
+		case t == "dict" && err != nil: // d[key]
+			index = &ast.Str{S: py.String(m[2])}
+
+		default:
+			return nil
+		}
+
+		arg = &ast.Subscript{Value: arg, Slice: &ast.Index{Value: index}, Ctx: ast.Load}
+	}
+
+	return arg
+}
+
+var rePercentVerb = regexp.MustCompile(`%[-+ #0]*(?:\d+|\*)?(?:\.(?:\d+|\*))?([a-zA-Z%])`)
+
+// "format" % args -> fmt.Sprintf("format", args...)
+//
+// A tuple provides all the arguments, any other value is a single argument (as in python).
+// The %s arguments are converted with runtime.Str and the %r arguments with runtime.Repr
+func (s *Scope) goPercentFormat(format string, right ast.Expr) *jen.Statement {
+	var args []ast.Expr
+
+	if tuple, ok := right.(*ast.Tuple); ok { // including a single element tuple (x,)
+		args = tuple.Elts
+	} else if s.exprType(right) == "tuple" { // a tuple variable: the values are the arguments
+		return jen.Qual("fmt", "Sprintf").Call(jen.Lit(format), s.goExpr(right).Op("..."))
+	} else {
+		args = []ast.Expr{right}
+	}
+
+	bformat := []byte(format)
+	params := []jen.Code{nil} // the format goes first
+	i := 0
+
+	for _, m := range rePercentVerb.FindAllStringSubmatchIndex(format, -1) {
+		verb := format[m[2]:m[3]]
+		if verb == "%" || i >= len(args) {
+			continue
+		}
+
+		arg := s.goExpr(args[i])
+
+		switch verb {
+		case "s":
+			if s.exprType(args[i]) != "str" {
+				arg = jen.Qual(goRuntime, "Str").Call(arg)
+			}
+
+		case "r":
+			arg = jen.Qual(goRuntime, "Repr").Call(arg)
+			bformat[m[2]] = 's'
+		}
+
+		params = append(params, arg)
+		i++
+	}
+
+	for _, arg := range args[i:] { // more arguments than verbs: let Sprintf report them
+		params = append(params, s.goExpr(arg))
+	}
+
+	params[0] = jen.Lit(string(bformat))
+	return jen.Qual("fmt", "Sprintf").Call(params...)
+}
+
+// check for a range(...) call
+func isRange(call *ast.Call) bool {
+	n, ok := call.Func.(*ast.Name)
+	return ok && n.Id == "range" && len(call.Args) >= 1
+}
+
+// the sign of an integer literal (1 or -1), or 0 if expr is not an integer literal
+func intSign(expr ast.Expr) int {
+	if unary, ok := expr.(*ast.UnaryOp); ok && (unary.Op == ast.USub || unary.Op == ast.UAdd) {
+		sign := intSign(unary.Operand)
+		if unary.Op == ast.USub {
+			sign = -sign
+		}
+
+		return sign
+	}
+
+	if n, ok := expr.(*ast.Num); ok && isInt(n) {
+		if n.N.(py.Int) < 0 {
+			return -1
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+func isInt(expr ast.Expr) bool {
+	if n, ok := expr.(*ast.Num); ok {
+		_, ok = n.N.(py.Int)
+		return ok
+	}
+
+	return false
+}
+
+// obj[k] = value -> obj.Set(k, value), if obj is a class that defines __setitem__ (nil otherwise)
+func (s *Scope) goSetItem(target *ast.Subscript, value *jen.Statement) *jen.Statement {
+	if i, ok := target.Slice.(*ast.Index); ok && s.hasMethod(target.Value, "__setitem__") {
+		return s.goExpr(target.Value).Dot("Set").Call(s.goExpr(i.Value), value)
+	}
+
+	return nil
+}
+
+// obj.x = value -> obj.SetX(value), if x is a property with a setter (nil otherwise)
+func (s *Scope) goSetProperty(target *ast.Attribute, value *jen.Statement) *jen.Statement {
+	if _, setter := s.attrProperty(target); setter != nil {
+		return s.goExpr(target.Value).Dot(setterName(target.Attr)).Call(value)
+	}
+
+	return nil
+}
+
+// x ** y or pow(x, y): integer power if both are integers, math.Pow otherwise
+func (s *Scope) goPow(x, y ast.Expr) *jen.Statement {
+	if s.exprType(x) == "int" && s.exprType(y) == "int" && intSign(y) >= 0 { // a negative exponent is a float
+		return jen.Qual(goRuntime, "IntPow").Call(s.goExpr(x), s.goExpr(y))
+	}
+
+	return jen.Qual("math", "Pow").Params(s.goExpr(x), s.goExpr(y))
+}
+
+// a[i:j] = xs, replacing the range in place
+func (s *Scope) goSetSlice(name ast.Expr, sl *ast.Slice, value ast.Expr) *jen.Statement {
+	start := jen.Lit(0)
+	end := jen.Len(s.goExpr(name))
+
+	if sl.Lower != nil {
+		start = s.goIndexValue(name, sl.Lower)
+	}
+	if sl.Upper != nil {
+		end = s.goIndexValue(name, sl.Upper)
+	}
+
+	return jen.Qual(goRuntime, "SetSlice").Call(jen.Op("&").Add(s.goExpr(name)), start, end, s.goExpr(value))
+}
+
+func (s *Scope) goIdentifiers(l []ast.Identifier) *jen.Statement {
+	return jen.ListFunc(func(g *jen.Group) {
+		for _, i := range l {
+			g.Add(goId(i))
+		}
+	})
+}
+
+func (s *Scope) strIdentifiers(l []ast.Identifier) string {
+	var sx []string
+	for _, i := range l {
+		sx = append(sx, string(i))
+	}
+
+	return strings.Join(sx, ",")
+}
+
+func (s *Scope) goInitialized(otype *jen.Statement, values []ast.Expr) *jen.Statement {
+	for i, v := range values {
+		if _, ok := v.(*ast.Starred); ok {
+			return s.goStarredInitialized(otype, values[:i], values[i:])
+		}
+	}
+
+	return jen.Parens(otype.Clone().ValuesFunc(func(g *jen.Group) {
+		for _, v := range values {
+			g.Add(s.goExpr(v))
+		}
+	}))
+}
+
+// [1, *rest, 9] -> append(append(runtime.List{1}, rest...), 9)
+func (s *Scope) goStarredInitialized(otype *jen.Statement, values, rest []ast.Expr) *jen.Statement {
+	stmt := s.goInitialized(otype, values)
+
+	for len(rest) > 0 {
+		if starred, ok := rest[0].(*ast.Starred); ok {
+			value := s.goExpr(starred.Value)
+			switch s.exprType(starred.Value) {
+			case "list", "tuple":
+			default:
+				value = jen.Qual(goRuntime, "ToList").Call(value)
+			}
+
+			stmt = jen.Append(stmt, value.Op("..."))
+			rest = rest[1:]
+			continue
+		}
+
+		// the plain values up to the next starred value
+		n := 1
+		for n < len(rest) {
+			if _, ok := rest[n].(*ast.Starred); ok {
+				break
+			}
+			n++
+		}
+
+		args := []jen.Code{stmt}
+		for _, v := range rest[:n] {
+			args = append(args, s.goExpr(v))
+		}
+
+		stmt = jen.Append(args...)
+		rest = rest[n:]
+	}
+
+	return stmt
+}
+
+func (s *Scope) goExprList(values []ast.Expr) *jen.Statement {
+	return jen.ListFunc(func(g *jen.Group) {
+		for _, v := range values {
+			g.Add(s.goExpr(v))
+		}
+	})
+}
+
+func (s *Scope) strExprList(values []ast.Expr) string {
+	var sx []string
+	for _, v := range values {
+		sx = append(sx, s.goExpr(v).GoString())
+	}
+
+	return strings.Join(sx, ",")
+}
+
+func (s *Scope) goExprOrList(expr ast.Expr) *jen.Statement {
+	if tuple, ok := expr.(*ast.Tuple); ok {
+		return s.goExpr(tuple.Elts)
+	}
+	return s.goExpr(expr)
+}
+
+func lenExpr(expr ast.Expr) int {
+	if tuple, ok := expr.(*ast.Tuple); ok {
+		return len(tuple.Elts)
+	}
+
+	return 1
+}
+
+func isNone(expr ast.Expr) bool {
+	if c, ok := expr.(*ast.NameConstant); ok {
+		return c.Value == py.None
+	}
+
+	return false
+}
+
+func isBytes(expr ast.Expr) bool {
+	_, ok := expr.(*ast.Bytes)
+	return ok
+}
+
+func isTuple(expr ast.Expr) bool {
+	_, ok := expr.(*ast.Tuple)
+	return ok
+}
+
+func isList(expr ast.Expr) bool {
+	_, ok := expr.(*ast.List)
+	return ok
+}
+
+// check if expr is the attribute base.attr (i.e. os.environ)
+func isAttribute(expr ast.Expr, base, attr string) bool {
+	if a, ok := expr.(*ast.Attribute); ok {
+		x, b, n := strAttribute(a)
+		return x == nil && b == base && n == attr
+	}
+
+	return false
+}
+
+// check for `__name__ == "__main__"`
+func isNameMain(expr ast.Expr) bool {
+	comp, ok := expr.(*ast.Compare)
+	if !ok {
+		return false
+	}
+	if len(comp.Ops) != 1 {
+		return false
+	}
+	if comp.Ops[0] != ast.Eq {
+		return false
+	}
+
+	name, ok := comp.Left.(*ast.Name)
+	if !ok {
+		return false
+	}
+	if string(name.Id) != "__name__" {
+		return false
+	}
+
+	str, ok := comp.Comparators[0].(*ast.Str)
+	if !ok {
+		return false
+	}
+	if string(str.S) != "__main__" {
+		return false
+	}
+
+	return true
+}
+
+// return the name of a decorator (i.e. "property" or "functools.cached_property")
+func decoratorName(d ast.Expr) string {
+	switch v := d.(type) {
+	case *ast.Name:
+		return string(v.Id)
+
+	case *ast.Attribute:
+		if x, b, a := strAttribute(v); x == nil {
+			return b + "." + a
+		}
+
+	case *ast.Call: // decorator with parameters
+		return decoratorName(v.Func)
+	}
+
+	return ""
+}
+
+// check if the decorator list contains any of the named decorators
+func hasDecorator(decorators []ast.Expr, names ...string) bool {
+	for _, d := range decorators {
+		dname := decoratorName(d)
+		for _, n := range names {
+			if dname == n {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isCachedProperty(f *ast.FunctionDef) bool {
+	return hasDecorator(f.DecoratorList, "cached_property", "functools.cached_property")
+}
+
+// check if the function is a property setter (@x.setter def x(self, value))
+func isSetter(f *ast.FunctionDef) bool {
+	return hasDecorator(f.DecoratorList, string(f.Name)+".setter")
+}
+
+// the name of the setter method for a property (x -> SetX)
+func setterName(name ast.Identifier) string {
+	return "Set" + strings.ToUpper(string(name[:1])) + string(name[1:])
+}
+
+// return the getter and setter of a class property, if defined
+func classProperty(cls *ast.ClassDef, name string) (getter, setter *ast.FunctionDef) {
+	for _, stmt := range cls.Body {
+		if f, ok := stmt.(*ast.FunctionDef); ok && string(f.Name) == name {
+			if isSetter(f) {
+				setter = f
+			} else if hasDecorator(f.DecoratorList, "property") || isCachedProperty(f) {
+				getter = f
+			}
+		}
+	}
+
+	return
+}
+
+func isDataclass(cls *ast.ClassDef) bool {
+	return hasDecorator(cls.DecoratorList, "dataclass", "dataclasses.dataclass")
+}
+
+func isEnum(cls *ast.ClassDef) bool {
+	return hasDecorator(cls.Bases, "Enum", "enum.Enum", "IntEnum", "enum.IntEnum")
+}
+
+// the constant for an enum member, prefixed with the enum name (Color.RED -> ColorRED)
+func enumMember(cls *ast.ClassDef, member string) *jen.Statement {
+	return goId(ast.Identifier(string(cls.Name) + member))
+}
+
+// return the class variables (single name assignments in the class body)
+func classVars(cls *ast.ClassDef) (vars []*ast.Assign) {
+	for _, stmt := range cls.Body {
+		if a, ok := stmt.(*ast.Assign); ok && len(a.Targets) == 1 {
+			if _, ok := a.Targets[0].(*ast.Name); ok {
+				vars = append(vars, a)
+			}
+		}
+	}
+
+	return
+}
+
+// the number of values returned by a function: n if all the return statements return a tuple of n values
+// (translated to a function with multiple return values), otherwise 1
+func returnArity(f *ast.FunctionDef) int {
+	n := 0
+
+	walkBody(f.Body, func(stmt ast.Stmt) {
+		ret, ok := stmt.(*ast.Return)
+		if !ok || n < 0 {
+			return
+		}
+
+		t, ok := ret.Value.(*ast.Tuple)
+		switch {
+		case !ok || len(t.Elts) < 2:
+			n = -1
+		case n == 0:
+			n = len(t.Elts)
+		case n != len(t.Elts):
+			n = -1
+		}
+	})
+
+	if n < 2 || isGenerator(f.Body) {
+		return 1
+	}
+
+	return n
+}
+
+// the number of values returned by a call to a known function (1 if unknown)
+func (s *Scope) callArity(expr ast.Expr) int {
+	if call, ok := expr.(*ast.Call); ok {
+		if f := s.calledFunc(call); f != nil {
+			return returnArity(f)
+		}
+	}
+
+	return 1
+}
+
+// call f for all the statements in body, including the ones in nested blocks (but not in nested functions)
+func walkBody(body []ast.Stmt, f func(ast.Stmt)) {
+	for _, stmt := range body {
+		f(stmt)
+
+		switch v := stmt.(type) {
+		case *ast.If:
+			walkBody(v.Body, f)
+			walkBody(v.Orelse, f)
+
+		case *ast.For:
+			walkBody(v.Body, f)
+			walkBody(v.Orelse, f)
+
+		case *ast.While:
+			walkBody(v.Body, f)
+			walkBody(v.Orelse, f)
+
+		case *ast.With:
+			walkBody(v.Body, f)
+
+		case *ast.Try:
+			walkBody(v.Body, f)
+			for _, h := range v.Handlers {
+				walkBody(h.Body, f)
+			}
+			walkBody(v.Orelse, f)
+			walkBody(v.Finalbody, f)
+		}
+	}
+}
+
+// return the instance attributes assigned in the methods (self.x = v or self.x += v), in order,
+// with the first assigned value (nil for an augmented assignment)
+func instanceAttributes(cls *ast.ClassDef) (names []ast.Identifier, values []ast.Expr) {
+	seen := map[ast.Identifier]bool{}
+
+	for _, a := range classVars(cls) {
+		seen[a.Targets[0].(*ast.Name).Id] = true
+	}
+
+	for _, stmt := range cls.Body {
+		if f, ok := stmt.(*ast.FunctionDef); ok {
+			seen[f.Name] = true // a method or property
+		}
+	}
+
+	for _, stmt := range cls.Body {
+		f, ok := stmt.(*ast.FunctionDef)
+		if !ok || f.Args == nil || len(f.Args.Args) == 0 || hasDecorator(f.DecoratorList, "staticmethod", "classmethod") {
+			continue
+		}
+
+		recv := f.Args.Args[0].Arg
+
+		add := func(target, value ast.Expr) {
+			attr, ok := target.(*ast.Attribute)
+			if !ok {
+				return
+			}
+
+			if name, ok := attr.Value.(*ast.Name); ok && name.Id == recv && !seen[attr.Attr] {
+				seen[attr.Attr] = true
+				names = append(names, attr.Attr)
+				values = append(values, value)
+			}
+		}
+
+		walkBody(f.Body, func(stmt ast.Stmt) {
+			switch v := stmt.(type) {
+			case *ast.Assign:
+				for _, t := range v.Targets {
+					if tuple, ok := t.(*ast.Tuple); ok {
+						for _, e := range tuple.Elts {
+							add(e, nil)
+						}
+					} else {
+						add(t, v.Value)
+					}
+				}
+
+			case *ast.AugAssign:
+				add(v.Target, nil)
+			}
+		})
+	}
+
+	return
+}
+
+func isYield(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Yield, *ast.YieldFrom:
+		return true
+	}
+
+	return false
+}
+
+// check if a function body contains yield statements (i.e. it's a generator)
+func isGenerator(body []ast.Stmt) bool {
+	for _, stmt := range body {
+		switch v := stmt.(type) {
+		case *ast.ExprStmt:
+			if isYield(v.Value) {
+				return true
+			}
+
+		case *ast.Assign:
+			if isYield(v.Value) {
+				return true
+			}
+
+		case *ast.If:
+			if isGenerator(v.Body) || isGenerator(v.Orelse) {
+				return true
+			}
+
+		case *ast.For:
+			if isGenerator(v.Body) || isGenerator(v.Orelse) {
+				return true
+			}
+
+		case *ast.While:
+			if isGenerator(v.Body) || isGenerator(v.Orelse) {
+				return true
+			}
+
+		case *ast.With:
+			if isGenerator(v.Body) {
+				return true
+			}
+
+		case *ast.Try:
+			if isGenerator(v.Body) || isGenerator(v.Orelse) || isGenerator(v.Finalbody) {
+				return true
+			}
+
+			for _, h := range v.Handlers {
+				if isGenerator(h.Body) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// return the names in a (possibly nested) target
+func exprIds(expr ast.Expr) (ids []ast.Identifier) {
+	switch t := expr.(type) {
+	case *ast.Tuple:
+		for _, x := range t.Elts {
+			ids = append(ids, exprIds(x)...)
+		}
+
+	case *ast.List:
+		for _, x := range t.Elts {
+			ids = append(ids, exprIds(x)...)
+		}
+
+	case *ast.Name:
+		ids = append(ids, t.Id)
+	}
+
+	return
+}
+
+// check if a tuple target contains nested tuples (i.e. `k, (v1, v2)`)
+func hasNestedTuple(expr ast.Expr) bool {
+	if tuple, ok := expr.(*ast.Tuple); ok {
+		for _, x := range tuple.Elts {
+			if isTuple(x) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// check for `d.items()`
+func isItems(expr ast.Expr) bool {
+	if c, ok := expr.(*ast.Call); ok {
+		if attr, ok := c.Func.(*ast.Attribute); ok {
+			return string(attr.Attr) == "items" && len(c.Args) == 0
+		}
+	}
+
+	return false
+}
+
+// return the dict for `d.items()`, to range over its keys and values
+func (s *Scope) goItemsDict(call *ast.Call) *jen.Statement {
+	d := call.Func.(*ast.Attribute).Value
+	if s.exprType(d) == "defaultdict" {
+		return s.goExpr(d).Dot("Dict")
+	}
+
+	return s.goExpr(d)
+}
+
+// check for `sorted(d.items())`, `list(d.items())` or `reversed(d.items())` (a list of (key, value) tuples)
+func isItemsList(expr ast.Expr) bool {
+	if c, ok := expr.(*ast.Call); ok && len(c.Args) == 1 {
+		if n, ok := c.Func.(*ast.Name); ok {
+			switch string(n.Id) {
+			case "sorted", "list", "reversed":
+				return isItems(c.Args[0])
+			}
+		}
+	}
+
+	return false
+}
+
+// the loop for a comprehension generator, with its conditions (all the `if` clauses of the generator,
+// joined with &&) as a guard inside the loop body, after the target assignment.
+// Return the loop and the statement where the body (or the next generator) should be added,
+// so that the conditions of an outer generator also guard the inner loops.
+func (s *Scope) gomprehension(c ast.Comprehension) (*jen.Statement, *jen.Statement) {
+	iter, assgn := s.goFor(c.Target, c.Iter)
+	cond := iter
+	if len(c.Ifs) > 0 {
+		ccond := s.goCond(c.Ifs[0])
+		for _, c := range c.Ifs[1:] {
+			ccond.Add(jen.Op("&&"))
+			ccond.Add(s.goCond(c))
+		}
+		cond = jen.If(ccond)
+		if assgn != nil {
+			iter.Block(assgn, cond)
+		} else {
+			iter.Block(cond)
+		}
+	} else if assgn != nil {
+		cond = jen.Null()
+		iter.Block(assgn, cond)
+	}
+
+	return iter, cond
+}
+
+// the []string argument of sep.join(arg): a generator, comprehension or map(f, l) is collected
+// into a []string (with runtime.Str if the elements are not strings)
+func (s *Scope) goJoinStrings(arg ast.Expr) *jen.Statement {
+	var elt ast.Expr
+	var generators []ast.Comprehension
+
+	switch v := arg.(type) {
+	case *ast.GeneratorExp:
+		elt, generators = v.Elt, v.Generators
+
+	case *ast.ListComp:
+		elt, generators = v.Elt, v.Generators
+
+	case *ast.Call: // map(f, l) -> (f(_x) for _x in l)
+		if name, ok := v.Func.(*ast.Name); !ok || name.Id != "map" || len(v.Args) != 2 {
+			return s.goExpr(arg)
+		}
+
+		x := &ast.Name{Id: "_x"}
+		elt = &ast.Call{Func: v.Args[0], Args: []ast.Expr{x}}
+		generators = []ast.Comprehension{{Target: x, Iter: v.Args[1]}}
+
+	default:
+		return s.goExpr(arg)
+	}
+
+	outer, inner := s.gomprehensions(generators)
+
+	value := s.goExpr(elt)
+	if s.exprType(elt) != "str" {
+		value = jen.Qual(goRuntime, "Str").Call(value)
+	}
+
+	inner.Add(jen.Block(jen.Id("js").Op("=").Append(jen.Id("js"), value)))
+	return jen.Func().Params().Params(jen.Id("js").Index().String()).Block(outer, jen.Return()).Call()
+}
+
+// the nested loops for the generators of a comprehension, where each generator is nested
+// in the body of the previous one (as in `[x*y for x in a for y in b]`).
+// Return the outermost loop and the innermost statement, where the element should be added as a block.
+func (s *Scope) gomprehensions(generators []ast.Comprehension) (*jen.Statement, *jen.Statement) {
+	outer, inner := s.gomprehension(generators[0])
+
+	for _, g := range generators[1:] {
+		outer1, inner1 := s.gomprehension(g)
+		inner.Add(jen.Block(outer1))
+		inner = inner1
+	}
+
+	return outer, inner
+}
+
+// print k=v either for function definitions (def=true) or for function call (def=false)
+func (s *Scope) goKvals(kk []*ast.Keyword, def bool) *jen.Statement {
+	return jen.ListFunc(func(g *jen.Group) {
+		if def {
+			for _, k := range kk {
+				g.Add(goId(k.Arg).Commentf("/*=%v*/", s.goExpr(k.Value).GoString()))
+			}
+		} else {
+			for _, k := range kk {
+				g.Commentf("/*%v=*/", string(k.Arg)).Add(s.goExpr(k.Value))
+			}
+		}
+	})
+}
+
+func (s *Scope) goExpr(expr interface{}) *jen.Statement {
+	if verbose {
+		log.Printf("XXX %T %#v\n\n", expr, expr)
+	}
+
+	switch v := expr.(type) {
+	case []ast.Expr:
+		return s.goExprList(v)
+
+	case []*ast.Keyword:
+		return jen.ListFunc(func(g *jen.Group) {
+			for _, k := range v {
+				g.Add(goId(k.Arg).Commentf("/*=%v*/", s.goExpr(k.Value).GoString()))
+			}
+		})
+
+	case *ast.Tuple:
+		return s.goInitialized(goTuple, v.Elts)
+
+	case *ast.List:
+		return s.goInitialized(goList, v.Elts)
+
+	case *ast.Dict:
+		return jen.Parens(goDict.Clone().Values(jen.DictFunc(func(d jen.Dict) {
+			for i, k := range v.Keys {
+				d[s.goExpr(k)] = s.goExpr(v.Values[i])
+			}
+		})))
+
+	case *ast.Num:
+		switch n := v.N.(type) {
+		case py.Int:
+			return jen.Lit(int(n))
+
+		case py.Float:
+			return jen.Lit(float64(n))
+
+		case py.Complex:
+			return jen.Lit(complex128(n))
+
+		default:
+			log.Printf("number %v at %v:%v", v.N, v.GetLineno(), v.GetColOffset())
+			panic("invalid number")
+		}
+
+	case ast.Identifier:
+		return goId(v)
+
+	case *ast.NameConstant:
+		switch v.Value {
+		case py.None:
+			return jen.Nil()
+
+		case py.True:
+			return jen.True()
+
+		case py.False:
+			return jen.False()
+
+		default:
+			s, _ := py.Str(v.Value)
+			return jen.Lit(string(s.(py.String)))
+		}
+
+	case *ast.Str:
+		return jen.Lit(string(v.S))
+
+	case *ast.Bytes: // b"..." -> []byte("...")
+		return jen.Index().Byte().Call(jen.Lit(string(v.S)))
+
+	case *ast.UnaryOp:
+		if v.Op == ast.Invert {
+			return jen.Op("-").Parens(s.goExpr(v.Operand).Op("+").Lit(1))
+		} else if v.Op == ast.Not {
+			return s.goNot(v.Operand)
+		} else {
+			return s.goUnary(v.Op).Add(s.goExpr(v.Operand))
+		}
+
+	case *ast.BoolOp:
+		stmt := s.goExpr(v.Values[0])
+		for _, x := range v.Values[1:] {
+			stmt.Add(s.goBoolOp(v.Op))
+			stmt.Add(s.goExpr(x))
+		}
+		return stmt
+
+	case *ast.BinOp:
+		if v.Op == ast.Modulo { // %
+			if _, ok := v.Left.(*ast.Str); ok && s.exprType(v.Right) == "dict" { // "%(name)s" % d
+				return jen.Qual(goRuntime, "FormatMap").Call(s.goExpr(v.Left), s.goExpr(v.Right))
+			}
+
+			if str, ok := v.Left.(*ast.Str); ok { // this is really a formatting operation
+				return s.goPercentFormat(string(str.S), v.Right)
+			}
+		}
+
+		if v.Op == ast.Pow { // **
+			if s.exprType(v) == "complex" {
+				return jen.Qual("math/cmplx", "Pow").Params(s.goExpr(v.Left), s.goExpr(v.Right))
+			}
+
+			return s.goPow(v.Left, v.Right)
+		}
+
+		if m, ok := operatorMethods[v.Op]; ok && s.hasMethod(v.Left, m) { // a + b -> a.Add(b)
+			return s.goExpr(v.Left).Dot(specialMethods[m].name).Call(s.goExpr(v.Right))
+		}
+
+		return s.goExpr(v.Left).Add(s.goOp(v.Op)).Add(s.goExpr(v.Right))
+
+	case *ast.Compare:
+		stmt := jen.Null()
+
+		left := s.goExpr(v.Left)
+		right := (*jen.Statement)(nil)
+		lexpr := v.Left
+
+		for i, op := range v.Ops {
+			if right != nil {
+				stmt.Op("&&")
+				left = right.Clone()
+				lexpr = v.Comparators[i-1]
+			}
+
+			right = s.goExpr(v.Comparators[i])
+
+			if m, ok := compareMethods[op]; ok && s.hasMethod(lexpr, m) { // a == b -> a.Eq(b)
+				stmt.Add(left.Dot(specialMethods[m].name).Call(right))
+			} else if op == ast.NotEq && s.hasMethod(lexpr, "__eq__") { // a != b -> !a.Eq(b)
+				stmt.Op("!").Add(left.Dot("Eq").Call(right))
+			} else if (op == ast.Is || op == ast.IsNot) && !isNone(lexpr) && !isNone(v.Comparators[i]) {
+				// object identity (x is None is a nil check)
+				if op == ast.IsNot {
+					stmt.Op("!")
+				}
+				stmt.Add(jen.Qual(goRuntime, "Identity").Call(left, right))
+			} else if op == ast.In {
+				stmt.Add(goContains.Clone().Call(right, left))
+			} else if op == ast.NotIn {
+				stmt.Op("!").Add(goContains.Clone().Call(right, left))
+			} else {
+				stmt.Add(left)
+				stmt.Add(s.goCmpOp(op))
+				stmt.Add(right)
+			}
+		}
+
+		return stmt
+
+	case *ast.Name:
+		if sym := s.goSymbol(string(v.Id)); sym != nil { // from module import name
+			return sym
+		}
+
+		if gobuiltins[string(v.Id)] && s.isDefined(v.Id) { // a variable shadowing a builtin
+			return goVarId(v.Id)
+		}
+
+		return goId(v.Id)
+
+	case *ast.Attribute:
+		if getter, _ := s.attrProperty(v); getter != nil { // obj.x -> obj.x()
+			return s.goExpr(v.Value).Dot(rename(string(v.Attr))).Call()
+		}
+
+		if (v.Attr == "real" || v.Attr == "imag") && s.exprType(v.Value) == "complex" { // z.real -> real(z)
+			return jen.Id(string(v.Attr)).Call(s.goExpr(v.Value))
+		}
+
+		x, b, a := strAttribute(v)
+		a = rename(a)
+
+		if x != nil {
+			return s.goExpr(x).Dot(a)
+		}
+
+		switch {
+		case b == "re" && a == "compile":
+			return jen.Qual("regexp", "MustCompile")
+
+		case b == "re" && a == "match":
+			return jen.Qual("regexp", "MatchString")
+
+		case b == "sys" && a == "argv":
+			return jen.Qual("os", "Args")
+
+		case b == "sys" && a == "stdin":
+			return jen.Qual("os", "Stdin")
+
+		case b == "sys" && a == "stdout":
+			return jen.Qual("os", "Stdout")
+
+		case b == "sys" && a == "stderr":
+			return jen.Qual("os", "Stderr")
+
+		case b == "sys.stdin":
+			return jen.Qual("os", "Stdin").Dot(a)
+
+		case b == "sys.stdout":
+			return jen.Qual("os", "Stdout").Dot(a)
+
+		case b == "sys.stderr":
+			return jen.Qual("os", "Stderr").Dot(a)
+
+		case b == "math" && a == "inf":
+			return jen.Qual("math", "Inf").Call(jen.Lit(1))
+
+		case b == "math" && a == "nan":
+			return jen.Qual("math", "NaN").Call()
+		}
+
+		if sym := s.goSymbol(b + "." + a); sym != nil {
+			return sym
+		}
+
+		if cls := s.classDef(ast.Identifier(b)); cls != nil && isEnum(cls) {
+			return enumMember(cls, a) // Color.RED -> ColorRED
+		}
+
+		if cls := s.nestedClass(v); cls != nil {
+			return goId(cls.Name) // Outer.Inner -> Outer_Inner
+		}
+
+		if parts := strings.Split(b, "."); len(parts) == 2 {
+			if cls := s.classDef(ast.Identifier(parts[0])); cls != nil && isEnum(cls) {
+				switch a {
+				case "name": // Color.RED.name -> ColorRED.Name()
+					return enumMember(cls, parts[1]).Dot("Name").Call()
+
+				case "value": // Color.RED.value -> int(ColorRED)
+					return goId(ast.Identifier(enumType(cls))).Call(enumMember(cls, parts[1]))
+				}
+			}
+		}
+
+		if imp, ok := s.imports[b]; ok {
+			if member, ok := s.members[b]; ok { // from datetime import datetime; datetime.now -> datetime.datetime.now
+				return jen.Qual(imp, member).Dot(a)
+			}
+
+			return jen.Qual(imp, a)
+		}
+
+		return jen.Id(b).Dot(a)
+
+	case *ast.Subscript:
+		if i, ok := v.Slice.(*ast.Index); ok && isAttribute(v.Value, "os", "environ") {
+			return jen.Qual("os", "Getenv").Call(s.goExpr(i.Value))
+		}
+
+		if _, ok := v.Slice.(*ast.Index); ok && v.Ctx == ast.Load && s.exprType(v.Value) == "bytes" {
+			return jen.Int().Call(s.goSlice(v.Value, v.Slice)) // indexing bytes returns an int
+		}
+
+		return s.goSlice(v.Value, v.Slice)
+
+	case *ast.Call:
+		return s.goCall(v)
+
+	case *ast.Lambda:
+		ss := s.Push()
+		args, _ := ss.goFunctionArguments(v.Args, false)
+		ret := jen.Return(ss.goExpr(v.Body))
+		ss.Pop(true)
+
+		if v.Args == nil || len(v.Args.Defaults) == 0 {
+			return jen.Func().Params(args).Add(goAny).Block(ret)
+		}
+
+		// the default values are evaluated once, when the lambda is defined
+		// (mostly used to capture values, i.e. `lambda x, r=r: x * r`),
+		// and used when the parameter is not passed (nil)
+		var defaults, body []jen.Code
+
+		n := len(v.Args.Args) - len(v.Args.Defaults)
+		for i, a := range v.Args.Args[n:] {
+			d := jen.Id("_" + string(a.Arg))
+			defaults = append(defaults, d.Clone().Op(":=").Add(s.goExpr(v.Args.Defaults[i])))
+			body = append(body, jen.If(goVarId(a.Arg).Op("==").Nil()).Block(goVarId(a.Arg).Op("=").Add(d)))
+		}
+
+		lambda := jen.Func().Params(args.Clone()).Add(goAny).Block(append(body, ret)...)
+		return jen.Func().Params().Func().Params(args).Add(goAny).Block(append(defaults, jen.Return(lambda))...).Call()
+
+	case *ast.Yield: // x = yield v
+		ret := jen.Nil()
+		if v.Value != nil {
+			ret = s.goExpr(v.Value)
+		}
+		return jen.Func().Params().Add(goAny).Block(
+			jen.Id("_c").Op("<-").Add(ret).Comment("yield"),
+			jen.Return(jen.Nil()).Comment("generator.send() is not supported, the value is always None"),
+		).Call()
+
+	case *ast.IfExp:
+		return jen.Func().Params().Block(
+			jen.If(s.goCond(v.Test)).
+				Block(jen.Return(s.goExpr(v.Body))).
+				Else().
+				Block(jen.Return(s.goExpr(v.Orelse)))).Call()
+
+	case *ast.ListComp:
+		outer, inner := s.gomprehensions(v.Generators)
+		inner.Add(jen.Block(jen.Id("lc").Op("=").Append(jen.Id("lc"), s.goExpr(v.Elt))))
+		return jen.Func().Params().Params(jen.Id("lc").Add(goList)).Block(outer, jen.Return(jen.Id("lc"))).Call()
+
+	case *ast.DictComp:
+		outer, inner := s.gomprehensions(v.Generators)
+		inner.Add(jen.Block(jen.Id("mm").Index(s.goExpr(v.Key)).Op("=").Add(s.goExpr(v.Value))))
+		return jen.Func().Params().Params(jen.Id("mm").Add(goDict)).Block(
+			jen.Id("mm").Op("=").Add(goDict).Values(),
+			outer,
+			jen.Return()).Call()
+
+	case *ast.GeneratorExp:
+		outer, inner := s.gomprehensions(v.Generators)
+		inner.Add(jen.Block(jen.Id("c").Op("<-").Add(s.goExpr(v.Elt))))
+		return jen.Func().Params().Params(jen.Id("c").Chan().Add(goAny)).Block(
+			jen.Id("c").Op("=").Make(jen.Chan().Add(goAny)),
+			jen.Go().Func().Params().Block(outer, jen.Close(jen.Id("c"))).Call(),
+			jen.Return(),
+		).Call()
+	}
+
+	return unknown("EXPR", expr)
+}
+
+// the Go type of a property value (the annotated return type, or Any)
+func (s *Scope) propertyType(f *ast.FunctionDef) *jen.Statement {
+	if f.Returns != nil && !isNone(f.Returns) {
+		return s.goExpr(f.Returns)
+	}
+
+	return goAny.Clone()
+}
+
+func goId(id ast.Identifier) *jen.Statement {
+	return jen.Id(rename(string(id)))
+}
+
+// the Go identifier for a variable or parameter, that can shadow a builtin
+func goVarId(id ast.Identifier) *jen.Statement {
+	if gobuiltins[string(id)] {
+		return jen.Id(string(id) + renameSuffix)
+	}
+
+	return goId(id)
+}
+
+func (s *Scope) goFunctionArguments(args *ast.Arguments, skipReceiver bool) (*jen.Statement, *ast.Arg) {
+	var recv *ast.Arg
+
+	if args == nil {
+		return jen.Null(), recv
+	}
+
+	var params []jen.Code
+
+	// the defaults are for the last len(Defaults) arguments
+	ndefaults := len(args.Args) - len(args.Defaults)
+
+	for i, arg := range args.Args {
+		if i == 0 && skipReceiver {
+			recv = arg
+			s.addName(arg.Arg)
+			continue
+		}
+
+		s.addName(arg.Arg)
+		s.setType(arg.Arg, "") // unless annotated
+
+		p := goVarId(arg.Arg)
+		if arg.Annotation != nil {
+			if t, ok := arg.Annotation.(*ast.Name); ok {
+				s.setType(arg.Arg, string(t.Id))
+			}
+
+			p.Add(s.goExpr(arg.Annotation))
+		} else {
+			p.Add(goAny)
+		}
+
+		if i >= ndefaults {
+			p.Commentf("/*=%v*/", s.goExpr(args.Defaults[i-ndefaults]).GoString())
+		}
+
+		params = append(params, p)
+	}
+
+	// the keyword-only arguments (after * or *args) come after the positional ones,
+	// but before *args (a variadic parameter needs to be the last one)
+	for i, arg := range args.Kwonlyargs {
 		s.addName(arg.Arg)
+		s.setType(arg.Arg, "")
+
+		p := goVarId(arg.Arg)
+		if arg.Annotation != nil {
+			p.Add(s.goExpr(arg.Annotation))
+		} else {
+			p.Add(goAny)
+		}
+
+		if i < len(args.KwDefaults) && args.KwDefaults[i] != nil {
+			p.Commentf("/*keyword-only=%v*/", s.goExpr(args.KwDefaults[i]).GoString())
+		} else {
+			p.Comment("/*keyword-only*/")
+		}
+
+		params = append(params, p)
+	}
+
+	// **kwargs is a map, before *args (a variadic parameter needs to be the last one)
+	if args.Kwarg != nil {
+		s.addName(args.Kwarg.Arg)
+		s.setType(args.Kwarg.Arg, "dict")
+
+		params = append(params, goVarId(args.Kwarg.Arg).Add(goDict))
+	}
+
+	if args.Vararg != nil {
+		s.addName(args.Vararg.Arg)
+		s.setType(args.Vararg.Arg, "list") // a slice
+
+		p := goVarId(args.Vararg.Arg).Op("...")
+		if args.Vararg.Annotation != nil {
+			p.Add(s.goExpr(args.Vararg.Annotation))
+		} else {
+			p.Add(goAny)
+		}
+
+		params = append(params, p)
+	}
+
+	return jen.List(params...), recv
+}
+
+// return the names referenced in the expression (or statement)
+func referencedNames(expr ast.Ast) (names []ast.Identifier) {
+	ast.Walk(expr, func(node ast.Ast) bool {
+		if name, ok := node.(*ast.Name); ok {
+			names = append(names, name.Id)
+		}
+		return true
+	})
+
+	return
+}
+
+// check if the name is referenced in the statements
+func usesName(body []ast.Stmt, id ast.Identifier) bool {
+	for _, stmt := range body {
+		for _, name := range referencedNames(stmt) {
+			if name == id {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// initialize the parameters whose default value references other names (def f(a, b=a)),
+// at the top of the function body and in parameter order, so that a default can use the previous parameters.
+// Only the parameters without annotation (Any) can be checked for a missing value (nil).
+func (s *Scope) goDefaults(args *ast.Arguments) {
+	if args == nil {
+		return
+	}
+
+	var params []*ast.Arg
+	var defaults []ast.Expr
+
+	ndefaults := len(args.Args) - len(args.Defaults)
+	for i, arg := range args.Args {
+		if i >= ndefaults {
+			params = append(params, arg)
+			defaults = append(defaults, args.Defaults[i-ndefaults])
+		}
+	}
+
+	for i, arg := range args.Kwonlyargs {
+		if i < len(args.KwDefaults) && args.KwDefaults[i] != nil {
+			params = append(params, arg)
+			defaults = append(defaults, args.KwDefaults[i])
+		}
+	}
+
+	// the parameters that are not initialized yet
+	pending := map[ast.Identifier]bool{}
+	for _, arg := range params {
+		pending[arg.Arg] = true
+	}
+
+	for i, arg := range params {
+		names := referencedNames(defaults[i])
+
+		later := ""
+		for _, name := range names {
+			if pending[name] {
+				later = string(name)
+				break
+			}
+		}
+
+		delete(pending, arg.Arg)
+
+		if len(names) == 0 || arg.Annotation != nil {
+			continue
+		}
+
+		if later != "" {
+			// in Python the default would reference an outer name, shadowed here by the parameter
+			s.Add(jen.Commentf("default %v=%v references %v, a parameter not initialized yet",
+				arg.Arg, s.goExpr(defaults[i]).GoString(), later))
+		} else {
+			s.Add(jen.If(goVarId(arg.Arg).Op("==").Nil()).Block(
+				goVarId(arg.Arg).Op("=").Add(s.goExpr(defaults[i]))).Commentf("default %v", arg.Arg))
+		}
+	}
+}
+
+func strAttribute(attr *ast.Attribute) (ast.Expr, string, string) {
+	var expr ast.Expr
+	var base string
+
+	switch v := attr.Value.(type) {
+	case *ast.Attribute:
+		_, b, a := strAttribute(v)
+		base = b + "." + a
+		expr = nil
+
+	case *ast.Name:
+		base = string(v.Id)
+		expr = nil
+
+	default:
+		expr = attr.Value
+	}
+
+	return expr, base, string(attr.Attr)
+}
+
+func (s *Scope) goCallParams(params ...ast.Expr) *jen.Statement {
+	return jen.ParamsFunc(func(g *jen.Group) {
+		for _, p := range params {
+			g.Add(s.goExpr(p))
+		}
+	})
+}
+
+// return the definition of the called function, if known
+func (s *Scope) calledFunc(call *ast.Call) *ast.FunctionDef {
+	if name, ok := call.Func.(*ast.Name); ok {
+		return s.funcDef(name.Id)
+	}
+
+	return nil
+}
+
+// the position of a (positional or keyword only) parameter in the Go function parameters, or -1
+func paramIndex(args *ast.Arguments, id ast.Identifier) int {
+	for i, a := range args.Args {
+		if a.Arg == id {
+			return i
+		}
+	}
+
+	for i, a := range args.Kwonlyargs {
+		if a.Arg == id {
+			return len(args.Args) + i
+		}
+	}
+
+	return -1
+}
+
+// the map for a **kwargs parameter, from the keyword arguments and the **d argument.
+// Note that in Python a keyword specified in both would be a TypeError,
+// here the explicit keyword arguments override the values in d.
+func (s *Scope) goKwargs(keywords []*ast.Keyword, kwargs ast.Expr) *jen.Statement {
+	if len(keywords) == 0 {
+		if kwargs == nil {
+			return jen.Nil()
+		}
+
+		return s.goExpr(kwargs)
+	}
+
+	kmap := goDict.Clone().Values(jen.DictFunc(func(d jen.Dict) {
+		for _, k := range keywords {
+			d[jen.Lit(string(k.Arg))] = s.goExpr(k.Value)
+		}
+	}))
+
+	if kwargs == nil {
+		return kmap
+	}
+
+	return jen.Qual(goRuntime, "MergeDicts").Call(s.goExpr(kwargs), kmap)
+}
+
+// print(args, sep=" ", end="\n") as fmt.Printf
+// print(args, file=f) as fmt.Fprintln(f, args) (or fmt.Fprintf, with sep or end)
+func (s *Scope) goPrintf(call *ast.Call) *jen.Statement {
+	var sep, end, file, flush ast.Expr
+	var others []*ast.Keyword
+
+	for _, k := range call.Keywords {
+		switch string(k.Arg) {
+		case "sep":
+			sep = k.Value
+		case "end":
+			end = k.Value
+		case "file":
+			file = k.Value
+		case "flush":
+			flush = k.Value
+		default:
+			others = append(others, k)
+		}
+	}
+
+	var args []jen.Code
+	pfunc, ffunc := "Println", "Fprintln"
+
+	if sep != nil || end != nil {
+		var format string
+
+		// a literal separator goes in the format, anything else is an argument
+		addString := func(v ast.Expr, def string) {
+			if v == nil {
+				format += def
+			} else if str, ok := v.(*ast.Str); ok {
+				format += strings.Replace(string(str.S), "%", "%%", -1)
+			} else {
+				format += "%v"
+				args = append(args, s.goExpr(v))
+			}
+		}
+
+		for i, arg := range call.Args {
+			if i > 0 {
+				addString(sep, " ")
+			}
+
+			format += "%v"
+			args = append(args, s.goExpr(arg))
+		}
+
+		addString(end, "\n")
+
+		args = append([]jen.Code{jen.Lit(format)}, args...)
+		pfunc, ffunc = "Printf", "Fprintf"
+	} else {
+		for _, arg := range call.Args {
+			args = append(args, s.goExpr(arg))
+		}
+	}
+
+	if len(others) > 0 {
+		args = append(args, s.goKvals(others, false))
+	}
+
+	stmt := jen.Qual("fmt", pfunc).Call(args...)
+	if file != nil { // file=sys.stderr -> fmt.Fprintln(os.Stderr, ...)
+		stmt = jen.Qual("fmt", ffunc).Call(append([]jen.Code{s.goExpr(file)}, args...)...)
+	}
+
+	if flush != nil { // the Go standard output is not buffered
+		stmt.Commentf("flush=%v (ignored)", s.goExpr(flush).GoString())
+	}
+
+	return stmt
+}
+
+// logging functions and their level
+var logLevels = map[string]string{
+	"debug":     "LogDebug",
+	"info":      "LogInfo",
+	"warning":   "LogWarning",
+	"warn":      "LogWarning",
+	"error":     "LogError",
+	"exception": "LogError",
+	"critical":  "LogCritical",
+}
+
+// python special methods translated to Go methods, with their return type (if fixed)
+var specialMethods = map[string]struct {
+	name    string
+	returns string
+}{
+	"__str__":  {"String", "string"},
+	"__repr__": {"GoString", "string"}, // as in fmt %#v
+	"__len__":  {"Len", "int"},
+
+	"__enter__": {"Enter", ""}, // as in runtime.Enter
+	"__exit__":  {"Exit", ""},  // as in runtime.Exit
+
+	"__getitem__": {"Get", ""},
+	"__setitem__": {"Set", ""},
+
+	"__add__":      {"Add", ""},
+	"__sub__":      {"Sub", ""},
+	"__mul__":      {"Mul", ""},
+	"__truediv__":  {"Div", ""},
+	"__floordiv__": {"FloorDiv", ""},
+	"__mod__":      {"Mod", ""},
+
+	"__eq__": {"Eq", "bool"},
+	"__ne__": {"Ne", "bool"},
+	"__lt__": {"Lt", "bool"},
+	"__le__": {"Le", "bool"},
+	"__gt__": {"Gt", "bool"},
+	"__ge__": {"Ge", "bool"},
+}
+
+// binary operators that can be overloaded by a class
+var operatorMethods = map[ast.OperatorNumber]string{
+	ast.Add:      "__add__",
+	ast.Sub:      "__sub__",
+	ast.Mult:     "__mul__",
+	ast.Div:      "__truediv__",
+	ast.FloorDiv: "__floordiv__",
+	ast.Modulo:   "__mod__",
+}
+
+// comparison operators that can be overloaded by a class
+var compareMethods = map[ast.CmpOp]string{
+	ast.Eq:    "__eq__",
+	ast.NotEq: "__ne__",
+	ast.Lt:    "__lt__",
+	ast.LtE:   "__le__",
+	ast.Gt:    "__gt__",
+	ast.GtE:   "__ge__",
+}
+
+func (s *Scope) goCall(call *ast.Call) *jen.Statement {
+	cfunc := s.goExpr(call.Func)
+
+	if isDefaultDict(call) {
+		factory := jen.Nil()
+		if len(call.Args) > 0 {
+			factory = s.goDefaultFactory(call.Args[0])
+		}
+
+		return jen.Qual(goRuntime, "NewDefaultDict").Call(factory)
+	}
+
+	switch ff := call.Func.(type) {
+	case *ast.Name:
+		if cls := s.classDef(ff.Id); cls != nil && isEnum(cls) {
+			cfunc = goId(cls.Name) // Color(1) -> Color(1), a conversion
+		} else if cls != nil {
+			if len(call.Args) == 0 && len(call.Keywords) > 0 && call.Starargs == nil && call.Kwargs == nil {
+				// Point(x=1, y=2) -> &Point{x: 1, y: 2}
+				return jen.Op("&").Add(goId(cls.Name)).Values(jen.DictFunc(func(d jen.Dict) {
+					for _, k := range call.Keywords {
+						d[goId(k.Arg)] = s.goExpr(k.Value)
+					}
+				}))
+			}
+
+			cfunc = jen.Id("New" + renameId(cls.Name))
+		}
+
+		switch string(ff.Id) {
+		case "print":
+			for _, k := range call.Keywords {
+				switch string(k.Arg) {
+				case "sep", "end", "file", "flush":
+					return s.goPrintf(call)
+				}
+			}
+
+			cfunc = jen.Qual("fmt", "Println") // check for print parameters, could be fmt.Print, fmt.Fprint, etc.
+
+		case "open":
+			cfunc = jen.Qual("os", "Open") // could also be os.OpenFile
+
+		case "isinstance": // isinstance(obj, type)
+			if len(call.Args) == 2 {
+				obj := s.goExpr(call.Args[0])
+				otype := s.goInstanceType(call.Args[1])
+				comment := jen.Commentf("isinstance(%v, %v)", obj.GoString(), s.goExpr(call.Args[1]).GoString())
+				return jen.Func().Params().Bool().Block(
+					comment,
+					jen.List(jen.Op("_"), jen.Id("ok")).Op(":=").Add(obj).Assert(otype),
+					jen.Return(jen.Id("ok")),
+				).Call()
+			}
+
+		case "type":
+			cfunc = jen.Qual("reflect", "Type")
+
+		case "str":
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "Str").Call(s.goExpr(call.Args[0]))
+			}
+
+		case "format": // format(value, spec)
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "Str").Call(s.goExpr(call.Args[0]))
+			}
+
+			if len(call.Args) == 2 {
+				if spec, ok := call.Args[1].(*ast.Str); ok {
+					if m := reFormatSpec.FindStringSubmatch(string(spec.S)); m != nil {
+						if f := goFormatSpec(s.goExpr(call.Args[0]), m[1:]); f != nil {
+							return f
+						}
+					}
+				}
+			}
+
+		case "repr":
+			if len(call.Args) == 1 {
+				if s.hasMethod(call.Args[0], "__repr__") {
+					return s.goExpr(call.Args[0]).Dot("GoString").Call()
+				}
+
+				return jen.Qual(goRuntime, "Repr").Call(s.goExpr(call.Args[0]))
+			}
+
+		case "len":
+			if len(call.Args) == 1 && s.hasMethod(call.Args[0], "__len__") {
+				return s.goExpr(call.Args[0]).Dot("Len").Call()
+			}
+
+		case "next": // next(gen) -> runtime.Next(gen), raising StopIteration when the generator is done
+			if len(call.Args) > 0 {
+				return jen.Qual(goRuntime, "Next").Call(s.goExprList(call.Args))
+			}
+
+		case "abs":
+			if len(call.Args) == 1 {
+				switch s.exprType(call.Args[0]) {
+				case "complex": // the magnitude
+					return jen.Qual("math/cmplx", "Abs").Call(s.goExpr(call.Args[0]))
+				case "float":
+					return jen.Qual("math", "Abs").Call(s.goExpr(call.Args[0]))
+				}
+			}
+
+		case "list", "tuple", "set", "dict": // conversions from iterables
+			tname := strings.Title(string(ff.Id))
+
+			if ff.Id == "dict" && (len(call.Keywords) > 0 || call.Kwargs != nil) { // dict(a=1, b=2)
+				if len(call.Args) == 0 {
+					return s.goKwargs(call.Keywords, call.Kwargs)
+				}
+
+				return jen.Qual(goRuntime, "MergeDicts").Call(
+					jen.Qual(goRuntime, "ToDict").Call(s.goExpr(call.Args[0])),
+					s.goKwargs(call.Keywords, call.Kwargs))
+			}
+
+			switch len(call.Args) {
+			case 0:
+				return jen.Qual(goRuntime, tname).Values()
+			case 1:
+				return jen.Qual(goRuntime, "To"+tname).Call(s.goExpr(call.Args[0]))
+			}
+
+		case "bytes", "bytearray": // both are a (mutable) []byte
+			if len(call.Args) == 0 {
+				return jen.Index().Byte().Values()
+			}
+
+			arg := call.Args[0]
+
+			switch t := s.exprType(arg); {
+			case t == "int": // bytes(n): n zero bytes
+				return jen.Make(jen.Index().Byte(), s.goExpr(arg))
+
+			case t == "bytes" && !isBytes(arg): // copy, since bytearray is mutable
+				return jen.Append(jen.Index().Byte().Call(jen.Nil()), s.goExpr(arg).Op("..."))
+
+			case t == "bytes": // bytearray(b"...")
+				return s.goExpr(arg)
+			}
+
+			return jen.Index().Byte().Call(s.goExpr(arg)) // bytes(s, "utf-8"), ignoring the encoding
+
+		case "ord":
+			if len(call.Args) == 1 {
+				return jen.Int().Call(jen.Index().Rune().Call(s.goExpr(call.Args[0])).Index(jen.Lit(0)))
+			}
+
+		case "chr":
+			if len(call.Args) == 1 {
+				return jen.String().Call(jen.Rune().Call(s.goExpr(call.Args[0])))
+			}
+
+		case "bool":
+			switch len(call.Args) {
+			case 0:
+				return jen.False()
+
+			case 1:
+				if b, ok := literalTruth(call.Args[0]); ok {
+					return jen.Lit(b)
+				}
+
+				return s.goCond(call.Args[0]) // runtime.Truthy, if the type is not known
+			}
+
+		case "zip":
+			return jen.Qual(goRuntime, "Zip").Call(s.goExprList(call.Args))
+
+		case "reversed":
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "Reversed").Call(s.goExpr(call.Args[0]))
+			}
+
+		case "sorted":
+			if len(call.Args) == 1 {
+				key, reverse := jen.Nil(), jen.False()
+				for _, k := range call.Keywords {
+					switch string(k.Arg) {
+					case "key":
+						key = s.goKeyFunc(k.Value)
+					case "reverse":
+						reverse = s.goCond(k.Value)
+					}
+				}
+
+				return jen.Qual(goRuntime, "Sorted").Call(s.goExpr(call.Args[0]), key, reverse)
+			}
+
+		case "pow":
+			if len(call.Args) == 2 {
+				return s.goPow(call.Args[0], call.Args[1])
+			} else if len(call.Args) == 3 { // modular exponentiation
+				return jen.Qual(goRuntime, "ModPow").Call(s.goExpr(call.Args[0]),
+					s.goExpr(call.Args[1]),
+					s.goExpr(call.Args[2]))
+			}
+		}
+
+	case *ast.Attribute:
+		if cls := s.nestedClass(ff); cls != nil { // Outer.Inner() -> NewOuter_Inner()
+			cfunc = jen.Id("New" + renameId(cls.Name))
+			break
+		}
+
+		if isAttribute(ff, "self", string(ff.Attr)) { // self.assertEqual(a, b)
+			if a := s.goTestAssert(call, string(ff.Attr)); a != nil {
+				return a
+			}
+		}
+
+		switch string(ff.Attr) {
+		case "send": // the value can't be sent to a channel-based generator
+			if s.exprType(ff.Value) == "chan" && len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "Next").Call(s.goExpr(ff.Value)).
+					Commentf("generator.send(%v) is not supported, the value is ignored", s.goExpr(call.Args[0]).GoString())
+			}
+
+		case "conjugate":
+			if len(call.Args) == 0 && s.exprType(ff.Value) == "complex" {
+				return jen.Qual("math/cmplx", "Conj").Call(s.goExpr(ff.Value))
+			}
+
+		case "read":
+			cfunc = s.goExpr(ff.Value).Dot("Read")
+
+		case "write":
+			cfunc = s.goExpr(ff.Value).Dot("Write")
+
+		case "close":
+			cfunc = s.goExpr(ff.Value).Dot("Close")
+
+		case "items": // the (key, value) pairs, as in `sorted(d.items())` (loops range over the dict directly)
+			if len(call.Args) == 0 && !s.hasMethod(ff.Value, "items") {
+				return jen.Qual(goRuntime, "Items").Call(s.goItemsDict(call))
+			}
+
+		case "append":
+			if st, ok := ff.Value.(*ast.Subscript); ok && len(call.Args) == 1 {
+				// d[k].append(v) -> d.Set(k, append(d.Get(k).(List), v))
+				value := jen.Id("append").Call(s.goExpr(st).Assert(goList), s.goExpr(call.Args[0]))
+				if set := s.goSetItem(st, value); set != nil {
+					return set
+				}
+			}
+
+			if len(call.Args) == 1 {
+				return s.goExpr(ff.Value).Op("=").Id("append").
+					Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "upper":
+			return jen.Qual("strings", "ToUpper").Call(s.goExpr(ff.Value))
+
+		case "lower":
+			return jen.Qual("strings", "ToLower").Call(s.goExpr(ff.Value))
+
+		case "startswith":
+			if len(call.Args) == 1 {
+				return jen.Qual("strings", "HasPrefix").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "endswith":
+			if len(call.Args) == 1 {
+				return jen.Qual("strings", "HasSuffix").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "strip":
+			if len(call.Args) == 0 {
+				return jen.Qual("strings", "TrimSpace").Call(s.goExpr(ff.Value))
+			} else if len(call.Args) == 1 {
+				return jen.Qual("strings", "Trim").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "lstrip":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "TrimLeft").Call(s.goExpr(ff.Value))
+			} else if len(call.Args) == 1 {
+				return jen.Qual("strings", "TrimLeft").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "rstrip":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "TrimRight").Call(s.goExpr(ff.Value))
+			} else if len(call.Args) == 1 {
+				return jen.Qual("strings", "TrimRight").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "split":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "Splits").Call(s.goExpr(ff.Value))
+			} else if len(call.Args) == 1 {
+				return jen.Qual("strings", "Split").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			} else if len(call.Args) == 2 {
+				return jen.Qual("strings", "SplitN").Call(s.goExpr(ff.Value),
+					s.goExpr(call.Args[0]),
+					s.goExpr(call.Args[1]).Op("+").Lit(1))
+			}
+
+		case "format":
+			if _, ok := ff.Value.(*ast.Str); ok || s.exprType(ff.Value) == "str" {
+				return s.goStrFormat(ff.Value, call)
+			}
+
+		case "format_map":
+			if str, ok := ff.Value.(*ast.Str); ok && len(call.Args) == 1 {
+				if format, ok := percentFormat(string(str.S)); ok {
+					return jen.Qual(goRuntime, "FormatMap").Call(jen.Lit(format), s.goExpr(call.Args[0]))
+				}
+			}
+
+		case "splitlines":
+			keepends := jen.False()
+			if len(call.Args) == 1 {
+				keepends = s.goCond(call.Args[0])
+			}
+			for _, k := range call.Keywords {
+				if k.Arg == "keepends" {
+					keepends = s.goCond(k.Value)
+				}
+			}
+
+			return jen.Qual(goRuntime, "SplitLines").Call(s.goExpr(ff.Value), keepends)
+
+		case "partition":
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "Partition").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "rpartition":
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "RPartition").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "join":
+			if len(call.Args) == 1 {
+				return jen.Qual("strings", "Join").Call(s.goJoinStrings(call.Args[0]), s.goExpr(ff.Value))
+			}
+
+		case "replace":
+			if len(call.Args) == 2 {
+				return jen.Qual("strings", "Replace").Call(s.goExpr(ff.Value),
+					s.goExpr(call.Args[0]),
+					s.goExpr(call.Args[1]),
+					jen.Lit(-1))
+			} else if len(call.Args) == 3 {
+				return jen.Qual("strings", "Replace").Call(s.goExpr(ff.Value),
+					s.goExpr(call.Args[0]),
+					s.goExpr(call.Args[1]),
+					s.goExpr(call.Args[2]))
+			}
+
+		case "count":
+			if len(call.Args) == 1 {
+				return jen.Qual("strings", "Count").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "zfill":
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "ZFill").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "center", "ljust", "rjust": // width[, fillchar]
+			if len(call.Args) == 1 || len(call.Args) == 2 {
+				fname := map[string]string{"center": "Center", "ljust": "LJust", "rjust": "RJust"}[string(ff.Attr)]
+				return jen.Qual(goRuntime, fname).Call(s.goExpr(ff.Value), s.goExprList(call.Args))
+			}
+
+		case "find": // only for strings (i.e. not ElementTree find), the index is in runes as for the string slices
+			if len(call.Args) >= 1 && len(call.Args) <= 3 && s.exprType(ff.Value) == "str" {
+				return jen.Qual(goRuntime, "Find").Call(s.goExpr(ff.Value), s.goExprList(call.Args))
+			}
+
+		case "rfind":
+			if len(call.Args) >= 1 && len(call.Args) <= 3 && s.exprType(ff.Value) == "str" {
+				return jen.Qual(goRuntime, "RFind").Call(s.goExpr(ff.Value), s.goExprList(call.Args))
+			}
+
+		case "index": // only for strings, since lists have an index method too
+			if len(call.Args) >= 1 && len(call.Args) <= 3 && s.exprType(ff.Value) == "str" {
+				return jen.Qual(goRuntime, "StrIndex").Call(s.goExpr(ff.Value), s.goExprList(call.Args))
+			}
+
+		case "isspace":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "IsSpace").Call(s.goExpr(ff.Value))
+			}
+
+		case "isalpha":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "IsAlpha").Call(s.goExpr(ff.Value))
+			}
+
+		case "isdigit", "isnumeric":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "IsDigit").Call(s.goExpr(ff.Value))
+			}
+
+		case "isupper":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "IsUpper").Call(s.goExpr(ff.Value))
+			}
+
+		case "islower":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "IsLower").Call(s.goExpr(ff.Value))
+			}
+
+		case "reverse":
+			if len(call.Args) == 0 {
+				return jen.Qual(goRuntime, "Reverse").Call(s.goExpr(ff.Value))
+			}
+
+		case "update":
+			if len(call.Args) == 1 && s.exprType(ff.Value) == "dict" {
+				return jen.Qual(goRuntime, "Update").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
+			}
+
+		case "pop":
+			// an integer index is a list.pop(i)
+			if s.exprType(ff.Value) == "dict" && ((len(call.Args) == 1 && !isInt(call.Args[0])) || len(call.Args) == 2) {
+				return jen.Qual(goRuntime, "DictPop").Call(append([]jen.Code{s.goExpr(ff.Value)}, s.goExpr(call.Args))...)
+			}
+
+		case "setdefault":
+			if len(call.Args) == 1 {
+				return jen.Qual(goRuntime, "SetDefault").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]), jen.Nil())
+			} else if len(call.Args) == 2 {
+				return jen.Qual(goRuntime, "SetDefault").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]), s.goExpr(call.Args[1]))
+			}
+		}
+
+		if isAttribute(ff.Value, "os", "environ") && string(ff.Attr) == "get" {
+			if len(call.Args) == 1 {
+				return jen.Qual("os", "Getenv").Call(s.goExpr(call.Args[0]))
+			} else if len(call.Args) == 2 {
+				return jen.Qual(goRuntime, "Getenv").Call(s.goExpr(call.Args[0]), s.goExpr(call.Args[1]))
+			}
+		}
+
+		if name, ok := ff.Value.(*ast.Name); ok {
+			switch {
+			case string(name.Id) == "sys" && string(ff.Attr) == "exit":
+				ret := jen.Lit(-1)
+				if len(call.Args) > 0 {
+					ret = s.goExpr(call.Args[0])
+				}
+				return jen.Qual("os", "Exit").Call(ret)
+
+			case string(name.Id) == "time" && string(ff.Attr) == "sleep" && len(call.Args) == 1:
+				tt := jen.Qual("time", "Duration").Parens(
+					s.goExpr(call.Args[0]).Op("*").Float64().Parens(jen.Qual("time", "Second")))
+				return jen.Qual("time", "Sleep").Call(tt)
+
+			case string(name.Id) == "time" && string(ff.Attr) == "time" && len(call.Args) == 0:
+				return jen.Qual("time", "Now").Call()
+
+			case string(name.Id) == "time" && (ff.Attr == "monotonic" || ff.Attr == "perf_counter") && len(call.Args) == 0:
+				return jen.Qual(goRuntime, "Monotonic").Call()
+
+			case string(name.Id) == "logging" && logLevels[string(ff.Attr)] != "" && len(call.Args) > 0:
+				// logging.info(msg, args...)
+				args := append([]jen.Code{jen.Qual(goRuntime, logLevels[string(ff.Attr)])}, s.goExpr(call.Args[0]))
+				for _, arg := range call.Args[1:] {
+					args = append(args, s.goExpr(arg))
+				}
+				return jen.Qual(goRuntime, "Log").Call(args...)
+
+			case string(name.Id) == "logging" && string(ff.Attr) == "basicConfig":
+				for _, k := range call.Keywords { // other keywords are ignored
+					if k.Arg == "level" {
+						return jen.Qual(goRuntime, "SetLogLevel").Call(s.goExpr(k.Value))
+					}
+				}
+				return jen.Comment("logging.basicConfig()")
+
+			case string(name.Id) == "json" && string(ff.Attr) == "loads" && len(call.Args) == 1:
+				return jen.Qual(goRuntime, "JSONLoads").Call(s.goExpr(call.Args[0]))
+
+			case string(name.Id) == "json" && string(ff.Attr) == "dumps" && len(call.Args) == 1:
+				args := []jen.Code{s.goExpr(call.Args[0])}
+				for _, k := range call.Keywords { // other keywords are ignored
+					if k.Arg == "indent" && !isNone(k.Value) {
+						args = append(args, s.goExpr(k.Value))
+					}
+				}
+				return jen.Qual(goRuntime, "JSONDumps").Call(args...)
+			}
+		}
+	}
+
+	if f := s.calledFunc(call); f != nil && f.Args != nil {
+		return s.goCallFunc(cfunc, f.Args, call)
+	}
+
+	var args []jen.Code
+	var kwargs *jen.Statement
+
+	keywords := call.Keywords
+
+	if call.Kwargs != nil {
+		kwargs = s.goKwargs(keywords, call.Kwargs)
+		keywords = nil
+	}
+
+	for _, arg := range call.Args {
+		args = append(args, s.goExpr(arg))
+	}
+
+	if len(keywords) > 0 {
+		args = append(args, s.goKvals(keywords, false))
+	}
+
+	if kwargs != nil {
+		args = append(args, kwargs)
+	}
+
+	if call.Starargs != nil {
+		// the arguments need to be spread to the function parameters at runtime
+		return jen.Qual(goRuntime, "Apply").Call(cfunc,
+			jen.Append(goList.Clone().Values(args...), s.goExpr(call.Starargs).Op("..."))).
+			Comment("f(*args)")
+	}
+
+	return cfunc.Call(args...)
+}
+
+// call a known function, passing the arguments in the order of its Go parameters (see goFunctionArguments):
+// the positional parameters, the keyword-only parameters, the **kwargs map and the *args values,
+// as in f(a, b, *args, c=1, **kw) -> f(a, b, c, kw, args...).
+// The keyword arguments go in the position of their parameter (or in the **kwargs map)
+// and the missing parameters get their default value.
+func (s *Scope) goCallFunc(cfunc *jen.Statement, fa *ast.Arguments, call *ast.Call) *jen.Statement {
+	nargs := len(fa.Args)
+	params := make([]jen.Code, nargs+len(fa.Kwonlyargs))
+
+	positional, extra := call.Args, []ast.Expr(nil) // extra are the values for *args
+	if len(positional) > nargs {
+		positional, extra = call.Args[:nargs], call.Args[nargs:]
+	}
+
+	for i, arg := range positional {
+		params[i] = s.goExpr(arg)
+	}
+
+	var others []*ast.Keyword // the keywords for the **kwargs map
+	for _, k := range call.Keywords {
+		if i := paramIndex(fa, k.Arg); i >= 0 {
+			params[i] = s.goExpr(k.Value)
+		} else {
+			others = append(others, k)
+		}
+	}
+
+	ndefaults := nargs - len(fa.Defaults)
+	for i, p := range params {
+		if p != nil {
+			continue
+		}
+
+		var def ast.Expr
+		if i >= nargs {
+			if j := i - nargs; j < len(fa.KwDefaults) {
+				def = fa.KwDefaults[j]
+			}
+		} else if i >= ndefaults {
+			def = fa.Defaults[i-ndefaults]
+		}
+
+		params[i] = s.goDefaultArg(def)
+	}
+
+	// the values after the positional parameters
+	kwvalues := append([]jen.Code{}, params[nargs:]...)
+	if fa.Kwarg != nil {
+		kwvalues = append(kwvalues, s.goKwargs(others, call.Kwargs))
+	}
+
+	if call.Starargs != nil && (len(call.Args) < nargs || fa.Vararg == nil) {
+		// the *args values fill the positional parameters at runtime, the other values go after them
+		values := jen.Append(goList.Clone().Values(s.goExprList(call.Args)), s.goExpr(call.Starargs).Op("..."))
+		if len(kwvalues) > 0 {
+			values = jen.Qual(goRuntime, "InsertArgs").Call(append([]jen.Code{values, jen.Lit(nargs)}, kwvalues...)...)
+		}
+
+		return jen.Qual(goRuntime, "Apply").Call(cfunc, values).Comment("f(*args)")
+	}
+
+	args := append(params[:nargs:nargs], kwvalues...)
+
+	switch {
+	case call.Starargs != nil && len(extra) > 0: // f(a, b, c, *l) -> f(a, b, append(List{c}, l...)...)
+		args = append(args, jen.Append(goList.Clone().Values(s.goExprList(extra)), s.goExpr(call.Starargs).Op("...")).Op("..."))
+
+	case call.Starargs != nil:
+		args = append(args, s.goExpr(call.Starargs).Op("..."))
+
+	default:
+		for _, arg := range extra {
+			args = append(args, s.goExpr(arg))
+		}
+	}
+
+	return cfunc.Call(args...)
+}
+
+// the value for a parameter missing in a call: the default value, or nil if there is no default
+// or if the default references other names (it's initialized in the function body, see goDefaults)
+func (s *Scope) goDefaultArg(def ast.Expr) *jen.Statement {
+	if def == nil || len(referencedNames(def)) > 0 {
+		return jen.Nil()
+	}
+
+	return s.goExpr(def)
+}
+
+// collect the names and values to assign for a (possibly nested) target,
+// where value is the source element for the target
+func (s *Scope) unpackTarget(target ast.Expr, value *jen.Statement, names, values *[]jen.Code) {
+	if tuple, ok := target.(*ast.Tuple); ok {
+		for i, x := range tuple.Elts {
+			s.unpackTarget(x, value.Clone().Assert(goTuple).Index(jen.Lit(i)), names, values)
+		}
+
+		return
+	}
+
+	*names = append(*names, s.goExpr(target))
+	*values = append(*values, value)
+}
+
+// return the for loop statement and, if needed, the assignment to the loop variables
+// (to be added at the beginning of the loop body)
+func (s *Scope) goFor(target, iter ast.Expr) (*jen.Statement, *jen.Statement) {
+	for _, id := range exprIds(target) {
+		s.addName(id)
+		s.setType(id, "") // the loop variables are untyped, unless set below
+	}
+
+	if c, ok := iter.(*ast.Call); ok { // check for "for x in range(n)"
+		//
+		// for x in range(y)
+		//
+		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "range" {
+			if len(c.Args) < 1 || len(c.Args) > 3 {
+				log.Printf("at %v:%v", iter.GetLineno(), iter.GetColOffset())
+				panic("range expects 1 to 3 arguments")
+			}
+
+			start := jen.Lit(0)
+			step := jen.Lit(1)
+			cmp := "<"
+
+			var stop jen.Code
+
+			if len(c.Args) == 1 {
+				stop = s.goExpr(c.Args[0])
+			} else {
+				start = s.goExpr(c.Args[0])
+				stop = s.goExpr(c.Args[1])
+
+				if len(c.Args) > 2 {
+					step = s.goExpr(c.Args[2])
+
+					switch intSign(c.Args[2]) {
+					case -1: // range(10, 0, -2)
+						cmp = ">"
+
+					case 0: // the direction depends on the step value
+						return jen.For(jen.List(jen.Op("_"), s.goExpr(target)).Op(":=").Range().
+							Qual(goRuntime, "Range").Call(start, stop, step)), nil
+					}
+				}
+			}
+
+			t := s.goExpr(target)
+
+			return jen.For(t.Clone().Op(":=").Add(start),
+				t.Clone().Op(cmp).Add(stop),
+				t.Clone().Op("+=").Add(step)), nil
+		}
+
+		//
+		// for x in reversed(range(y)), for x in reversed(l)
+		//
+		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "reversed" && len(c.Args) == 1 && lenExpr(target) == 1 {
+			t := s.goExpr(target)
+
+			if r, ok := c.Args[0].(*ast.Call); ok && isRange(r) && len(r.Args) <= 2 {
+				start := jen.Lit(0)
+				stop := s.goExpr(r.Args[0])
+				if len(r.Args) == 2 {
+					start = s.goExpr(r.Args[0])
+					stop = s.goExpr(r.Args[1])
+				}
+
+				return jen.For(t.Clone().Op(":=").Add(stop).Op("-").Lit(1),
+					t.Clone().Op(">=").Add(start),
+					t.Clone().Op("--")), nil
+			}
+
+			if _, ok := c.Args[0].(*ast.Name); ok { // descending index loop
+				seq := s.goExpr(c.Args[0])
+				return jen.For(jen.Id("_i").Op(":=").Len(seq).Op("-").Lit(1),
+						jen.Id("_i").Op(">=").Lit(0),
+						jen.Id("_i").Op("--")),
+					t.Clone().Op(":=").Add(seq.Clone()).Index(jen.Id("_i"))
+			}
+		}
+
+		//
+		// for i, v in enumerate(l)
+		//
+		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "enumerate" && len(c.Args) == 1 && len(c.Keywords) == 0 {
+			return jen.For(s.goExprOrList(target).Op(":=").Range().Add(s.goExpr(c.Args[0]))), nil
+		}
+
+		//
+		// for i, v in enumerate(l, start)
+		//
+		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "enumerate" && lenExpr(target) == 2 && !hasNestedTuple(target) {
+			var start ast.Expr
+			if len(c.Args) == 2 {
+				start = c.Args[1]
+			} else if len(c.Args) == 1 && len(c.Keywords) == 1 && c.Keywords[0].Arg == "start" {
+				start = c.Keywords[0].Value
+			}
+
+			if start != nil {
+				t := target.(*ast.Tuple)
+				return jen.For(jen.List(jen.Id("_i"), s.goExpr(t.Elts[1])).Op(":=").Range().Add(s.goExpr(c.Args[0]))),
+					s.goExpr(t.Elts[0]).Op(":=").Id("_i").Op("+").Add(s.goExpr(start))
+			}
+		}
+
+		//
+		// for a, b in zip(x, y)
+		//
+		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "zip" && len(c.Args) > 0 && lenExpr(target) == len(c.Args) && !hasNestedTuple(target) {
+			t := target.(*ast.Tuple)
+
+			indexed := true // index the sequences directly, if they are known lists or tuples
+			for _, arg := range c.Args {
+				if t := s.exprType(arg); t != "list" && t != "tuple" {
+					indexed = false
+				}
+			}
+
+			var values []jen.Code
+
+			if indexed { // stop at the shortest sequence
+				cond := jen.Null()
+				for i, arg := range c.Args {
+					if i > 0 {
+						cond.Op("&&")
+					}
+					cond.Id("_i").Op("<").Len(s.goExpr(arg))
+					values = append(values, s.goExpr(arg).Index(jen.Id("_i")))
+				}
+
+				return jen.For(jen.Id("_i").Op(":=").Lit(0), cond, jen.Id("_i").Op("++")),
+					s.goExprList(t.Elts).Op(":=").List(values...)
+			}
+
+			for i := range t.Elts {
+				values = append(values, jen.Id("_t").Assert(goTuple).Index(jen.Lit(i)))
+			}
+
+			return jen.For(jen.List(jen.Op("_"), jen.Id("_t")).Op(":=").Range().Add(s.goExpr(iter))),
+				s.goExprList(t.Elts).Op(":=").List(values...)
+		}
+
+		//
+		// for v in iterator
+		//
+		//if lenExpr(target) <= 1 {
+		//    return jen.For(jen.List(jen.Op("_"), s.goExpr(target)).Op(":=").Range().Add(s.goExpr(iter))), nil
+		//}
+	}
+
+	//
+	// for k, v in d.items()
+	//
+	if isItems(iter) && lenExpr(target) == 2 && !hasNestedTuple(target) {
+		return jen.For(s.goExprOrList(target).Op(":=").Range().Add(s.goItemsDict(iter.(*ast.Call)))), nil
+	}
+
+	//
+	// for n in itertools.count() (a channel)
+	//
+	if s.exprType(iter) == "chan" && lenExpr(target) == 1 {
+		return jen.For(s.goExpr(target).Op(":=").Range().Add(s.goExpr(iter))), nil
+	}
+
+	//
+	// for line in file
+	//
+	if s.exprType(iter) == "file" && lenExpr(target) == 1 {
+		return jen.For(jen.Id("_s").Op(":=").Qual("bufio", "NewScanner").Call(s.goExpr(iter)),
+				jen.Id("_s").Dot("Scan").Call(),
+				jen.Empty()),
+			s.goExpr(target).Op(":=").Id("_s").Dot("Text").Call().Comment("line without newline")
+	}
+
+	//
+	// for c in "abc" (one character strings, not bytes)
+	//
+	if name, ok := target.(*ast.Name); ok && s.exprType(iter) == "str" {
+		s.setType(name.Id, "str")
+		return jen.For(jen.List(jen.Op("_"), jen.Id("_r")).Op(":=").Range().Add(s.goExpr(iter))),
+			goVarId(name.Id).Op(":=").String().Call(jen.Id("_r"))
+	}
+
+	//
+	// for k, (v1, v2) in d.items()
+	// for (a, b), c in iterable
+	//
+	if hasNestedTuple(target) {
+		t := target.(*ast.Tuple)
+
+		var names, values []jen.Code
+
+		if isItems(iter) && len(t.Elts) == 2 {
+			s.unpackTarget(t.Elts[0], jen.Id("_k"), &names, &values)
+			s.unpackTarget(t.Elts[1], jen.Id("_v"), &names, &values)
+
+			return jen.For(jen.List(jen.Id("_k"), jen.Id("_v")).Op(":=").Range().Add(s.goItemsDict(iter.(*ast.Call)))),
+				jen.List(names...).Op(":=").List(values...)
+		}
+
+		for i, x := range t.Elts {
+			s.unpackTarget(x, jen.Id("_t").Assert(goTuple).Index(jen.Lit(i)), &names, &values)
+		}
+
+		return jen.For(jen.List(jen.Op("_"), jen.Id("_t")).Op(":=").Range().Add(s.goExpr(iter))),
+			jen.List(names...).Op(":=").List(values...)
+	}
+
+	// for x in iterable
+	// for k, v in dict
+	// for a,b,c in tuple iterable
+
+	switch lenExpr(target) {
+	case 0:
+		log.Fatalf("for without target: %#v", target)
+
+	case 1:
+		return jen.For(jen.List(jen.Op("_"), s.goExpr(target)).Op(":=").Range().Add(s.goExpr(iter))), nil
+
+	case 2:
+		if !isItemsList(iter) { // for k, v in sorted(d.items()) unpacks the tuples
+			return jen.For(s.goExprOrList(target).Op(":=").Range().Add(s.goExpr(iter))), nil
+		}
+
+		fallthrough
+
+	default:
+		t := target.(*ast.Tuple)
+		return jen.For(jen.List(jen.Op("_"), jen.Id("_t")).Commentf("/* %s */", s.strExprList(t.Elts)).Op(":=").Range().Add(s.goExpr(iter))),
+			s.goExprList(t.Elts).Op(":=").ListFunc(func(g *jen.Group) {
+				for i := range t.Elts {
+					g.Add(jen.Id("_t").Assert(goTuple).Index(jen.Lit(i)))
+				}
+			})
+	}
+
+	return nil, nil // shouldn't get here
+}
+
+func (s *Scope) goAssign(assign *ast.Assign) (*jen.Statement, *jen.Statement, *jen.Statement) {
+	goType := goValueType(assign.Value)
+
+	if len(assign.Targets) == 1 && (isTuple(assign.Targets[0]) || isList(assign.Targets[0])) {
+		return s.goExprOrList(assign.Targets[0]), s.goExprOrList(assign.Value), goType
+	}
+
+	if s.callArity(assign.Value) > 1 { // x = f(), with f returning multiple values
+		return s.goExpr(assign.Targets), jen.Qual(goRuntime, "MakeTuple").Call(s.goExpr(assign.Value)), goTuple.Clone()
+	}
+
+	return s.goExpr(assign.Targets), s.goExpr(assign.Value), goType
+}
+
+// the Go type of a literal value (or Any)
+func goValueType(value ast.Expr) *jen.Statement {
+	goType := goAny.Clone()
+
+	switch t := value.(type) {
+	case *ast.Tuple:
+		goType = goTuple.Clone()
+
+	case *ast.List:
+		goType = goList.Clone()
+
+	case *ast.Dict:
+		goType = goDict.Clone()
+
+	case *ast.Str:
+		goType = jen.String()
+
+	case *ast.Num:
+		switch t.N.(type) {
+		case py.Int:
+			goType = jen.Int()
+
+		case py.Float:
+			goType = jen.Float64()
+
+		case py.Complex:
+			goType = jen.Complex128()
+		}
+	}
+
+	return goType
+}
+
+// the (python) type of a literal value, for the types known to goValueType
+func valueType(value ast.Expr) string {
+	switch t := value.(type) {
+	case *ast.Tuple:
+		return "tuple"
+
+	case *ast.List:
+		return "list"
+
+	case *ast.Dict:
+		return "dict"
+
+	case *ast.Str:
+		return "str"
+
+	case *ast.Num:
+		switch t.N.(type) {
+		case py.Int:
+			return "int"
+
+		case py.Float:
+			return "float"
+
+		case py.Complex:
+			return "complex"
+		}
+	}
 
-		p := goId(arg.Arg)
-		if arg.Annotation != nil {
-			p.Add(s.goExpr(arg.Annotation))
-		} else {
-			p.Add(goAny)
+	return ""
+}
+
+// the list of arguments to forward the function parameters to another function
+func forwardArguments(args *ast.Arguments) []jen.Code {
+	var fwd []jen.Code
+
+	for _, a := range args.Args {
+		fwd = append(fwd, goVarId(a.Arg))
+	}
+
+	for _, a := range args.Kwonlyargs {
+		fwd = append(fwd, goVarId(a.Arg))
+	}
+
+	if args.Kwarg != nil {
+		fwd = append(fwd, goVarId(args.Kwarg.Arg))
+	}
+
+	if args.Vararg != nil {
+		fwd = append(fwd, goVarId(args.Vararg.Arg).Op("..."))
+	}
+
+	return fwd
+}
+
+// generate the NewClass constructor, calling __init__ if defined
+func (s *Scope) goConstructor(cls *ast.ClassDef) *jen.Statement {
+	name := renameId(cls.Name)
+
+	init := classMethod(cls, "__init__")
+	if init == nil && isDataclass(cls) {
+		return s.goDataclassConstructor(cls)
+	}
+
+	if init == nil || init.Args == nil || len(init.Args.Args) == 0 {
+		return jen.Func().Id("New" + name).Params().Op("*").Id(name).Block(
+			jen.Return(jen.Op("&").Id(name).Values())).Line()
+	}
+
+	ss := s.Push()
+	params, recv := ss.goFunctionArguments(init.Args, true)
+	ss.Pop(true)
+
+	fwd := *init.Args
+	fwd.Args = fwd.Args[1:] // skip receiver
+
+	self := goId(recv.Arg)
+	return jen.Func().Id("New"+name).Params(params).Op("*").Id(name).Block(
+		self.Clone().Op(":=").Op("&").Id(name).Values(),
+		self.Clone().Dot("__init__").Call(forwardArguments(&fwd)...),
+		jen.Return(self.Clone()),
+	).Line()
+}
+
+func isLiteral(expr ast.Expr) bool {
+	switch v := expr.(type) {
+	case *ast.Num, *ast.Str:
+		return true
+
+	case *ast.NameConstant:
+		return v.Value != py.None
+
+	case *ast.UnaryOp:
+		_, ok := v.Operand.(*ast.Num)
+		return ok && (v.Op == ast.USub || v.Op == ast.UAdd)
+	}
+
+	return false
+}
+
+// check for a constant definition (an UPPERCASE new name assigned to a literal value)
+func (s *Scope) isConstant(assign *ast.Assign) bool {
+	if len(assign.Targets) != 1 || !isLiteral(assign.Value) {
+		return false
+	}
+
+	name, ok := assign.Targets[0].(*ast.Name)
+	if !ok || strings.ToUpper(string(name.Id)) != string(name.Id) || strings.ToLower(string(name.Id)) == string(name.Id) {
+		return false
+	}
+
+	for curr := s; curr != nil; curr = curr.prev {
+		if _, ok := curr.vars[string(name.Id)]; ok {
+			return false
 		}
+	}
 
-		params = append(params, p)
+	return !isReassigned(s.topScope().module, name.Id)
+}
+
+// check if a module level name is assigned more than once at the top level, or declared global in a function
+func isReassigned(module []ast.Stmt, id ast.Identifier) bool {
+	n := 0
+
+	walkBody(module, func(stmt ast.Stmt) {
+		switch v := stmt.(type) {
+		case *ast.Assign:
+			for _, t := range v.Targets {
+				if assignsName(t, id) {
+					n++
+				}
+			}
+
+		case *ast.AugAssign:
+			if assignsName(v.Target, id) {
+				n++
+			}
+
+		case *ast.For:
+			if assignsName(v.Target, id) {
+				n++
+			}
+		}
+	})
+
+	if n > 1 {
+		return true
 	}
 
-	for i, arg := range args.Kwonlyargs {
-		s.addName(arg.Arg)
+	global := false
 
-		p := goId(arg.Arg)
-		if arg.Annotation != nil {
-			p.Add(s.goExpr(arg.Annotation))
-		} else {
-			p.Add(goAny)
+	for _, stmt := range module {
+		ast.Walk(stmt, func(node ast.Ast) bool {
+			if g, ok := node.(*ast.Global); ok {
+				for _, name := range g.Names {
+					if name == id {
+						global = true
+					}
+				}
+			}
+			return !global
+		})
+	}
+
+	return global
+}
+
+// check if the assignment target is (or contains, for a tuple) the name
+func assignsName(target ast.Expr, id ast.Identifier) bool {
+	switch t := target.(type) {
+	case *ast.Name:
+		return t.Id == id
+
+	case *ast.Tuple:
+		for _, e := range t.Elts {
+			if assignsName(e, id) {
+				return true
+			}
 		}
 
-		p.Commentf("/*=%v*/", s.goExpr(args.KwDefaults[i]).GoString())
-		params = append(params, p)
+	case *ast.List:
+		for _, e := range t.Elts {
+			if assignsName(e, id) {
+				return true
+			}
+		}
 	}
 
-	if args.Vararg != nil {
-		s.addName(args.Vararg.Arg)
+	return false
+}
 
-		p := goId(args.Vararg.Arg).Comment("/*...*/")
-		if args.Vararg.Annotation != nil {
-			p.Add(s.goExpr(args.Vararg.Annotation))
+// convert the definition of a named tuple to a struct and its constructor:
+//
+//	Point = NamedTuple("Point", [("x", int), ("y", int)])
+//	Point = namedtuple("Point", "x y", defaults=[0])
+func (s *Scope) goNamedTuple(assign *ast.Assign) *jen.Statement {
+	if len(assign.Targets) != 1 {
+		return nil
+	}
+
+	name, ok := assign.Targets[0].(*ast.Name)
+	if !ok {
+		return nil
+	}
+
+	call, ok := assign.Value.(*ast.Call)
+	if !ok || len(call.Args) != 2 {
+		return nil
+	}
+
+	typed := false
+
+	switch fname := decoratorName(call.Func); fname {
+	case "NamedTuple", "typing.NamedTuple":
+		typed = true
+
+	case "namedtuple", "collections.namedtuple":
+
+	default:
+		return nil
+	}
+
+	var fields []ast.Identifier
+	var types []*jen.Statement
+
+	switch f := call.Args[1].(type) {
+	case *ast.Str: // "x y" or "x, y"
+		for _, n := range strings.Fields(strings.Replace(string(f.S), ",", " ", -1)) {
+			fields = append(fields, ast.Identifier(n))
+			types = append(types, goAny)
+		}
+
+	case *ast.List, *ast.Tuple: // ["x", "y"] or [("x", int), ("y", int)]
+		var elts []ast.Expr
+		if l, ok := f.(*ast.List); ok {
+			elts = l.Elts
 		} else {
-			p.Add(goAny)
+			elts = f.(*ast.Tuple).Elts
 		}
 
-		params = append(params, p)
+		for _, el := range elts {
+			if str, ok := el.(*ast.Str); ok && !typed {
+				fields = append(fields, ast.Identifier(str.S))
+				types = append(types, goAny)
+			} else if t, ok := el.(*ast.Tuple); ok && typed && len(t.Elts) == 2 {
+				str, ok := t.Elts[0].(*ast.Str)
+				if !ok {
+					return nil
+				}
+
+				fields = append(fields, ast.Identifier(str.S))
+				types = append(types, s.goExpr(t.Elts[1]))
+			} else {
+				return nil
+			}
+		}
+
+	default:
+		return nil
 	}
 
-	if args.Kwarg != nil {
-		s.addName(args.Kwarg.Arg)
+	var defaults []ast.Expr
+	for _, k := range call.Keywords {
+		if string(k.Arg) == "defaults" {
+			switch d := k.Value.(type) {
+			case *ast.List:
+				defaults = d.Elts
+			case *ast.Tuple:
+				defaults = d.Elts
+			}
+		}
+	}
 
-		p := goId(args.Kwarg.Arg).Comment("/*...*/")
-		if args.Vararg.Annotation != nil {
-			p.Add(s.goExpr(args.Kwarg.Annotation))
-		} else {
-			p.Add(goAny)
+	s.classes[string(name.Id)] = &ast.ClassDef{Name: name.Id}
+
+	typeName := goId(name.Id)
+	ndefaults := len(fields) - len(defaults)
+
+	structdef := jen.Type().Add(typeName.Clone()).StructFunc(func(g *jen.Group) {
+		for i, f := range fields {
+			g.Add(goId(f).Add(types[i]))
 		}
+	}).Line()
 
-		params = append(params, p)
+	params := jen.ParamsFunc(func(g *jen.Group) {
+		for i, f := range fields {
+			p := goId(f).Add(types[i])
+			if i >= ndefaults {
+				p.Commentf("/*=%v*/", s.goExpr(defaults[i-ndefaults]).GoString())
+			}
+			g.Add(p)
+		}
+	})
+
+	values := jen.DictFunc(func(d jen.Dict) {
+		for _, f := range fields {
+			d[goId(f)] = goId(f)
+		}
+	})
+
+	constructor := jen.Func().Id("New" + renameId(name.Id)).Add(params).Op("*").Add(typeName.Clone()).Block(
+		jen.Return(jen.Op("&").Add(typeName.Clone()).Values(values))).Line()
+
+	return structdef.Line().Add(constructor)
+}
+
+// convert a constant definition (without const, to allow grouping)
+func (s *Scope) goConstant(assign *ast.Assign) *jen.Statement {
+	s.newNames(assign.Targets)
+	s.setType(assign.Targets[0].(*ast.Name).Id, s.exprType(assign.Value))
+
+	return s.goExpr(assign.Targets[0]).Op("=").Add(s.goExpr(assign.Value))
+}
+
+// generate the constructor for a @dataclass, with the class variables as parameters
+// (the Python 3.6 annotated fields can't be parsed yet)
+func (s *Scope) goDataclassConstructor(cls *ast.ClassDef) *jen.Statement {
+	name := renameId(cls.Name)
+	fields := classVars(cls)
+
+	params := jen.ParamsFunc(func(g *jen.Group) {
+		for _, f := range fields {
+			_, value, typ := s.goAssign(f)
+			g.Add(s.goExpr(f.Targets[0]).Add(typ).Commentf("/*=%v*/", value.GoString()))
+		}
+	})
+
+	values := jen.DictFunc(func(d jen.Dict) {
+		for _, f := range fields {
+			d[s.goExpr(f.Targets[0])] = s.goExpr(f.Targets[0])
+		}
+	})
+
+	return jen.Func().Id("New" + name).Add(params).Op("*").Id(name).Block(
+		jen.Return(jen.Op("&").Id(name).Values(values))).Line()
+}
+
+// generate the String and Eq methods for a @dataclass
+// (unless the class defines __str__ or __eq__)
+func (s *Scope) goDataclassMethods(cls *ast.ClassDef) *jen.Statement {
+	name := renameId(cls.Name)
+	fields := classVars(cls)
+	stmt := jen.Null()
+
+	if classMethod(cls, "__str__") == nil {
+		var format []string
+		var args []jen.Code
+
+		for _, f := range fields {
+			fname := f.Targets[0].(*ast.Name).Id
+			format = append(format, string(fname)+"=%v")
+			args = append(args, jen.Qual(goRuntime, "Repr").Call(jen.Id("self").Dot(rename(string(fname)))))
+		}
+
+		args = append([]jen.Code{jen.Lit(name + "(" + strings.Join(format, ", ") + ")")}, args...)
+		stmt.Func().Params(jen.Id("self").Op("*").Id(name)).Id("String").Params().String().Block(
+			jen.Return(jen.Qual("fmt", "Sprintf").Call(args...))).Line().Line()
 	}
 
-	// XXX: what is arg.Defaults ?
+	if classMethod(cls, "__eq__") == nil {
+		eq := jen.True()
+		for i, f := range fields {
+			fname := rename(string(f.Targets[0].(*ast.Name).Id))
+
+			var cmp *jen.Statement
+			switch s.exprType(f.Value) {
+			case "int", "float", "complex", "str", "bool":
+				cmp = jen.Id("self").Dot(fname).Op("==").Id("other").Dot(fname)
+			default: // lists, dicts and other values that are not comparable with ==
+				cmp = jen.Qual(goRuntime, "Equal").Call(jen.Id("self").Dot(fname), jen.Id("other").Dot(fname))
+			}
 
-	return jen.List(params...), recv
+			if i == 0 {
+				eq = cmp
+			} else {
+				eq.Op("&&").Add(cmp)
+			}
+		}
+
+		stmt.Func().Params(jen.Id("self").Op("*").Id(name)).Id("Eq").Params(jen.Id("other").Op("*").Id(name)).Bool().Block(
+			jen.Return(eq)).Line()
+	}
+
+	return stmt
 }
 
-func strAttribute(attr *ast.Attribute) (ast.Expr, string, string) {
-	var expr ast.Expr
-	var base string
+// convert an assignment with a single target
+func (s *Scope) goAssignStmt(assign *ast.Assign) *jen.Statement {
+	// a[i:j] = xs
+	if st, ok := assign.Targets[0].(*ast.Subscript); ok {
+		if sl, ok := st.Slice.(*ast.Slice); ok && sl.Step == nil {
+			return s.goSetSlice(st.Value, sl, assign.Value)
+		}
 
-	switch v := attr.Value.(type) {
-	case *ast.Attribute:
-		_, b, a := strAttribute(v)
-		base = b + "." + a
-		expr = nil
+		if set := s.goSetItem(st, s.goExpr(assign.Value)); set != nil {
+			return set
+		}
+	}
 
-	case *ast.Name:
-		base = string(v.Id)
-		expr = nil
+	// obj.x = v, with a property setter
+	if attr, ok := assign.Targets[0].(*ast.Attribute); ok {
+		if set := s.goSetProperty(attr, s.goExpr(assign.Value)); set != nil {
+			return set
+		}
+	}
 
-	default:
-		expr = attr.Value
+	// a, d[k] = x, y: declare the new names separately, then assign with =
+	if tuple, ok := assign.Targets[0].(*ast.Tuple); ok {
+		return s.goAssignTuple(tuple, assign)
 	}
 
-	return expr, base, string(attr.Attr)
+	// the new names are defined first, so that a name shadowing a builtin is renamed
+	isNew := s.newNames(assign.Targets)
+
+	target, value, _ := s.goAssign(assign)
+	stmt := target.Op("=").Add(value)
+	if isNew {
+		stmt = jen.Var().Add(stmt)
+
+		if name, ok := assign.Targets[0].(*ast.Name); ok {
+			s.setType(name.Id, s.exprType(assign.Value))
+		}
+	} else if name, ok := assign.Targets[0].(*ast.Name); ok && s.exprType(assign.Value) != s.nameType(name.Id) {
+		s.clearType(name.Id) // reassigned with a different (or unknown) type
+	}
+
+	return stmt
 }
 
-func (s *Scope) goCallParams(params ...ast.Expr) *jen.Statement {
-	return jen.ParamsFunc(func(g *jen.Group) {
-		for _, p := range params {
-			g.Add(s.goExpr(p))
+func (s *Scope) goAssignTuple(tuple *ast.Tuple, assign *ast.Assign) *jen.Statement {
+	values, _ := assign.Value.(*ast.Tuple)
+	if values != nil && len(values.Elts) != len(tuple.Elts) {
+		values = nil
+	}
+
+	var names []*ast.Name
+	var types []string
+
+	for i, e := range tuple.Elts {
+		if name, ok := e.(*ast.Name); ok && s.newNames([]ast.Expr{name}) {
+			t := ""
+			if values != nil {
+				t = s.exprType(values.Elts[i])
+				s.setType(name.Id, t)
+			}
+
+			names = append(names, name)
+			types = append(types, t)
 		}
-	})
+	}
+
+	target, value, _ := s.goAssign(assign)
+
+	if len(names) > 0 && len(names) == len(tuple.Elts) {
+		return jen.Var().Add(target).Op("=").Add(value)
+	}
+
+	stmt := jen.Null()
+	for i, name := range names {
+		stmt.Var().Add(goVarId(name.Id)).Add(goBasicType(types[i])).Line()
+	}
+
+	return stmt.Add(target.Op("=").Add(value))
+}
+
+// the zero value for a python type (nil for anything but the basic types)
+func zeroValue(t string) *jen.Statement {
+	switch t {
+	case "int":
+		return jen.Lit(0)
+	case "float":
+		return jen.Lit(0.0)
+	case "complex":
+		return jen.Lit(complex(0, 0))
+	case "str":
+		return jen.Lit("")
+	case "bool":
+		return jen.False()
+	}
+
+	return jen.Nil()
+}
+
+// the Go type for a basic python type, or Any
+func goBasicType(t string) *jen.Statement {
+	switch t {
+	case "int", "float", "complex", "str", "bool":
+		return goId(ast.Identifier(t))
+	}
+
+	return goAny.Clone()
+}
+
+// assert test, msg -> runtime.Assert(test, n, "msg")
+func (s *Scope) goAssertStmt(v *ast.Assert) *jen.Statement {
+	if noAssert { // the assertions are disabled, as in optimized python
+		return jen.Commentf("assert %v", s.goExpr(v.Test).GoString())
+	}
+
+	line, msg := jen.Lit(v.GetLineno()), s.assertMessage(v.Msg)
+
+	// assert isinstance(x, T) -> switch x.(type) { case T: default: runtime.Assert(false, msg) }
+	if call, ok := v.Test.(*ast.Call); ok && len(call.Args) == 2 {
+		if name, ok := call.Func.(*ast.Name); ok && name.Id == "isinstance" {
+			var types []jen.Code
+
+			if tuple, ok := call.Args[1].(*ast.Tuple); ok { // isinstance(x, (T1, T2))
+				for _, t := range tuple.Elts {
+					types = append(types, s.goInstanceType(t))
+				}
+			} else {
+				types = append(types, s.goInstanceType(call.Args[1]))
+			}
+
+			return jen.Switch(s.goExpr(call.Args[0]).Assert(jen.Type())).Block(
+				jen.Case(types...),
+				jen.Default().Add(goAssert.Call(jen.False(), line, msg)),
+			)
+		}
+	}
+
+	// assert a < b -> runtime.AssertCmp(a, "<", b, n, msg), to report the values of the operands
+	if cmp, ok := v.Test.(*ast.Compare); ok && len(cmp.Ops) == 1 {
+		op := cmp.Ops[0]
+
+		if m, ok := compareMethods[op]; !ok || !s.hasMethod(cmp.Left, m) {
+			a, b := s.goExpr(cmp.Left), s.goExpr(cmp.Comparators[0])
+
+			if op == ast.Eq {
+				return jen.Qual(goRuntime, "AssertEqual").Call(a, b, line, msg)
+			}
+
+			if name, ok := assertCmpOps[op]; ok {
+				return jen.Qual(goRuntime, "AssertCmp").Call(a, jen.Lit(name), b, line, msg)
+			}
+		}
+	}
+
+	return goAssert.Call(s.goCond(v.Test), line, msg)
+}
+
+// the comparison operators supported by runtime.AssertCmp
+var assertCmpOps = map[ast.CmpOp]string{
+	ast.NotEq: "!=",
+	ast.Lt:    "<",
+	ast.LtE:   "<=",
+	ast.Gt:    ">",
+	ast.GtE:   ">=",
+	ast.In:    "in",
+	ast.NotIn: "not in",
+}
+
+// the assertion message (an empty string if there is no message)
+func (s *Scope) assertMessage(msg ast.Expr) *jen.Statement {
+	if msg == nil {
+		return jen.Lit("")
+	}
+
+	if s.exprType(msg) == "str" { // i.e. "bad value %v" % x
+		return s.goExpr(msg)
+	}
+
+	return jen.Qual(goRuntime, "Str").Call(s.goExpr(msg))
 }
 
-func (s *Scope) goCall(call *ast.Call) *jen.Statement {
-	cfunc := s.goExpr(call.Func)
+// the number of arguments (before msg) of the unittest assertions
+var testAssertions = map[string]int{
+	"assertEqual":     2,
+	"assertNotEqual":  2,
+	"assertTrue":      1,
+	"assertFalse":     1,
+	"assertIn":        2,
+	"assertNotIn":     2,
+	"assertIsNone":    1,
+	"assertIsNotNone": 1,
+}
+
+// self.assertEqual(a, b) and the other unittest assertions -> runtime.AssertEqual(a, b, n, "msg"), runtime.Assert(...)
+// Returns nil if this is not a known assertion.
+func (s *Scope) goTestAssert(call *ast.Call, method string) *jen.Statement {
+	if method == "assertRaises" { // self.assertRaises(E, f, args...) -> runtime.AssertRaises(func() { f(args...) }, "E")
+		if len(call.Args) < 2 {
+			return nil
+		}
+
+		f := &ast.Call{Func: call.Args[1], Args: call.Args[2:], Keywords: call.Keywords}
+		f.Lineno, f.ColOffset = call.Lineno, call.ColOffset
+
+		args := append([]jen.Code{jen.Func().Params().Block(s.goExpr(f))}, exceptionNames(call.Args[0])...)
+		return jen.Qual(goRuntime, "AssertRaises").Call(args...)
+	}
+
+	n, ok := testAssertions[method]
+	if !ok || len(call.Args) < n {
+		return nil
+	}
+
+	var msg ast.Expr
+	if len(call.Args) > n {
+		msg = call.Args[n]
+	}
+
+	for _, k := range call.Keywords {
+		if k.Arg == "msg" {
+			msg = k.Value
+		}
+	}
+
+	line, message := jen.Lit(call.GetLineno()), s.assertMessage(msg)
+
+	var a, b *jen.Statement
+
+	a = s.goExpr(call.Args[0])
+	if n > 1 {
+		b = s.goExpr(call.Args[1])
+	}
 
-	switch ff := call.Func.(type) {
-	case *ast.Name:
-		switch string(ff.Id) {
-		case "print":
-			cfunc = jen.Qual("fmt", "Println") // check for print parameters, could be fmt.Print, fmt.Fprint, etc.
+	switch method {
+	case "assertEqual":
+		return jen.Qual(goRuntime, "AssertEqual").Call(a, b, line, message)
 
-		case "open":
-			cfunc = jen.Qual("os", "Open") // could also be os.OpenFile
+	case "assertNotEqual":
+		return goAssert.Clone().Call(jen.Op("!").Qual(goRuntime, "Equal").Call(a, b), line, message)
 
-		case "isinstance": // isinstance(obj, type)
-			if len(call.Args) == 2 {
-				obj := s.goExpr(call.Args[0])
-				otype := s.goExpr(call.Args[1])
-				comment := jen.Commentf("isinstance(%v, %v)", obj.GoString(), otype.GoString())
-				if attr, ok := call.Args[1].(*ast.Attribute); ok {
-					otype = jen.Commentf("/*%v*/", s.goExpr(attr.Value).GoString()).Add(s.goExpr(attr.Attr))
-				}
-				return jen.Func().Params().Bool().Block(
-					comment,
-					jen.List(jen.Op("_"), jen.Id("ok")).Op(":=").Add(obj).Assert(otype),
-					jen.Return(jen.Id("ok")),
-				).Call()
-			}
+	case "assertTrue":
+		return goAssert.Clone().Call(s.goCond(call.Args[0]), line, message)
 
-		case "type":
-			cfunc = jen.Qual("reflect", "Type")
-		}
+	case "assertFalse":
+		return goAssert.Clone().Call(s.goNot(call.Args[0]), line, message)
 
-	case *ast.Attribute:
-		switch string(ff.Attr) {
-		case "read":
-			cfunc = s.goExpr(ff.Value).Dot("Read")
+	case "assertIn":
+		return goAssert.Clone().Call(goContains.Clone().Call(b, a), line, message)
 
-		case "write":
-			cfunc = s.goExpr(ff.Value).Dot("Write")
+	case "assertNotIn":
+		return goAssert.Clone().Call(jen.Op("!").Add(goContains.Clone()).Call(b, a), line, message)
 
-		case "close":
-			cfunc = s.goExpr(ff.Value).Dot("Close")
+	case "assertIsNone":
+		return goAssert.Clone().Call(a.Op("==").Nil(), line, message)
 
-		case "items": // as in `for k, v in dict(a=1).items()`
-			return s.goExpr(ff.Value) // remove items
+	case "assertIsNotNone":
+		return goAssert.Clone().Call(a.Op("!=").Nil(), line, message)
+	}
 
-		case "append":
-			if len(call.Args) == 1 {
-				return s.goExpr(ff.Value).Op("=").Id("append").
-					Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+	return nil
+}
 
-		case "upper":
-			return jen.Qual("strings", "ToUpper").Call(s.goExpr(ff.Value))
+// the exception names, for runtime.Suppress and runtime.Raises (E or (E1, E2))
+func exceptionNames(exprs ...ast.Expr) []jen.Code {
+	var names []jen.Code
 
-		case "lower":
-			return jen.Qual("strings", "ToLower").Call(s.goExpr(ff.Value))
+	for _, expr := range exprs {
+		switch v := expr.(type) {
+		case *ast.Name:
+			names = append(names, jen.Lit(string(v.Id)))
 
-		case "startswith":
-			if len(call.Args) == 1 {
-				return jen.Qual("strings", "HasPrefix").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+		case *ast.Attribute:
+			names = append(names, jen.Lit(string(v.Attr)))
 
-		case "endswith":
-			if len(call.Args) == 1 {
-				return jen.Qual("strings", "HasSuffix").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+		case *ast.Tuple:
+			names = append(names, exceptionNames(v.Elts...)...)
+		}
+	}
 
-		case "strip":
-			if len(call.Args) == 0 {
-				return jen.Qual("strings", "TrimSpace").Call(s.goExpr(ff.Value))
-			} else if len(call.Args) == 1 {
-				return jen.Qual("strings", "Trim").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+	return names
+}
 
-		case "lstrip":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "TrimLeft").Call(s.goExpr(ff.Value))
-			} else if len(call.Args) == 1 {
-				return jen.Qual("strings", "TrimLeft").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+// the Go type for an isinstance type argument (a pointer for the known classes)
+func (s *Scope) goInstanceType(expr ast.Expr) *jen.Statement {
+	switch v := expr.(type) {
+	case *ast.Attribute:
+		return jen.Commentf("/*%v*/", s.goExpr(v.Value).GoString()).Add(s.goExpr(v.Attr))
 
-		case "rstrip":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "TrimRight").Call(s.goExpr(ff.Value))
-			} else if len(call.Args) == 1 {
-				return jen.Qual("strings", "TrimRight").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+	case *ast.Name:
+		if s.classDef(v.Id) != nil {
+			return jen.Op("*").Add(goId(v.Id))
+		}
+	}
 
-		case "split":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "Splits").Call(s.goExpr(ff.Value))
-			} else if len(call.Args) == 1 {
-				return jen.Qual("strings", "Split").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			} else if len(call.Args) == 2 {
-				return jen.Qual("strings", "SplitN").Call(s.goExpr(ff.Value),
-					s.goExpr(call.Args[0]),
-					s.goExpr(call.Args[1]).Op("+").Lit(1))
-			}
+	return s.goExpr(expr)
+}
 
-		case "join":
-			if len(call.Args) == 1 {
-				return jen.Qual("strings", "Join").Call(s.goExpr(call.Args[0]), s.goExpr(ff.Value))
-			}
+// known context managers, translated to a deferred call (deferred is true) or to an assignment.
+// Returns nil if the context manager is not known.
+func (s *Scope) goContextManager(item *ast.WithItem) (stmt *jen.Statement, deferred bool) {
+	call, ok := item.ContextExpr.(*ast.Call)
+	if !ok {
+		return nil, false
+	}
 
-		case "replace":
-			if len(call.Args) == 2 {
-				return jen.Qual("strings", "Replace").Call(s.goExpr(ff.Value),
-					s.goExpr(call.Args[0]),
-					s.goExpr(call.Args[1]),
-					jen.Lit(-1))
-			} else if len(call.Args) == 3 {
-				return jen.Qual("strings", "Replace").Call(s.goExpr(ff.Value),
-					s.goExpr(call.Args[0]),
-					s.goExpr(call.Args[1]),
-					s.goExpr(call.Args[2]))
-			}
+	if isAttribute(call.Func, "self", "assertRaises") { // with self.assertRaises(E) -> defer runtime.Raises("E")
+		return jen.Defer().Qual(goRuntime, "Raises").Call(exceptionNames(call.Args...)...), true
+	}
 
-		case "count":
-			if len(call.Args) == 1 {
-				return jen.Qual("strings", "Count").Call(s.goExpr(ff.Value), s.goExpr(call.Args[0]))
-			}
+	switch s.importedName(call.Func) {
+	case "contextlib.suppress": // with suppress(E1, E2) -> defer runtime.Suppress("E1", "E2")
+		return jen.Defer().Qual(goRuntime, "Suppress").Call(exceptionNames(call.Args...)...), true
 
-		case "isspace":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "IsSpace").Call(s.goExpr(ff.Value))
-			}
+	case "contextlib.nullcontext": // with nullcontext(x) as v -> v := x
+		if item.OptionalVars == nil {
+			return jen.Null(), false
+		}
 
-		case "isalpha":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "IsAlpha").Call(s.goExpr(ff.Value))
-			}
+		value := jen.Nil()
+		if len(call.Args) > 0 {
+			value = s.goExpr(call.Args[0])
+		}
 
-		case "isdigit", "isnumeric":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "IsDigit").Call(s.goExpr(ff.Value))
-			}
+		return s.goExpr(item.OptionalVars).Op(":=").Add(value), false
+	}
 
-		case "isupper":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "IsUpper").Call(s.goExpr(ff.Value))
+	return nil, false
+}
+
+// check if the body has a break, continue, return or raise (a return), that would jump out of a function literal
+func hasJump(body []ast.Stmt) bool {
+	for _, stmt := range body {
+		switch v := stmt.(type) {
+		case *ast.Break, *ast.Continue, *ast.Return, *ast.Raise:
+			return true
+
+		case *ast.If:
+			if hasJump(v.Body) || hasJump(v.Orelse) {
+				return true
 			}
 
-		case "islower":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "IsLower").Call(s.goExpr(ff.Value))
+		case *ast.With:
+			if hasJump(v.Body) {
+				return true
 			}
 
-		case "reverse":
-			if len(call.Args) == 0 {
-				return jen.Qual(goRuntime, "Reverse").Call(s.goExpr(ff.Value))
+		case *ast.Try:
+			if hasJump(v.Body) || hasJump(v.Orelse) || hasJump(v.Finalbody) {
+				return true
 			}
-		}
 
-		if name, ok := ff.Value.(*ast.Name); ok {
-			switch {
-			case string(name.Id) == "sys" && string(ff.Attr) == "exit":
-				ret := jen.Lit(-1)
-				if len(call.Args) > 0 {
-					ret = s.goExpr(call.Args[0])
+			for _, h := range v.Handlers {
+				if hasJump(h.Body) {
+					return true
 				}
-				return jen.Qual("os", "Exit").Call(ret)
+			}
 
-			case string(name.Id) == "time" && string(ff.Attr) == "sleep" && len(call.Args) == 1:
-				tt := jen.Qual("time", "Duration").Parens(
-					s.goExpr(call.Args[0]).Op("*").Float64().Parens(jen.Qual("time", "Second")))
-				return jen.Qual("time", "Sleep").Call(tt)
+		case *ast.For, *ast.While: // break and continue are for the inner loop
+			ret := false
+			walkBody([]ast.Stmt{stmt}, func(s ast.Stmt) {
+				switch s.(type) {
+				case *ast.Return, *ast.Raise:
+					ret = true
+				}
+			})
 
-			case string(name.Id) == "time" && string(ff.Attr) == "time" && len(call.Args) == 0:
-				return jen.Qual("time", "Now").Call()
+			if ret {
+				return true
 			}
 		}
 	}
 
-	var args []jen.Code
-
-	for _, arg := range call.Args {
-		args = append(args, s.goExpr(arg))
-	}
+	return false
+}
 
-	if len(call.Keywords) > 0 {
-		args = append(args, s.goKvals(call.Keywords, false))
+// check for a call to open(...)
+func isOpen(expr ast.Expr) bool {
+	if call, ok := expr.(*ast.Call); ok {
+		if name, ok := call.Func.(*ast.Name); ok {
+			return name.Id == "open"
+		}
 	}
 
-	if call.Starargs != nil {
-		args = append(args, s.goExpr(call.Starargs).Comment("/*...*/"))
-	}
+	return false
+}
 
-	if call.Kwargs != nil {
-		args = append(args, s.goExpr(call.Kwargs).Comment("/*...*/"))
+// the base type of an enum: int for IntEnum, otherwise the type of the member values (int or str)
+func enumType(cls *ast.ClassDef) string {
+	if !hasDecorator(cls.Bases, "IntEnum", "enum.IntEnum") {
+		if vars := classVars(cls); len(vars) > 0 {
+			if _, ok := vars[0].Value.(*ast.Str); ok {
+				return "str"
+			}
+		}
 	}
 
-	return cfunc.Call(args...)
+	return "int"
 }
 
-func (s *Scope) goFor(target, iter ast.Expr) (*jen.Statement, []ast.Expr) {
-	for _, id := range exprIds(target) {
-		s.addName(id)
-	}
+// class Color(Enum): RED = 1 -> type Color int; const ( ColorRED Color = 1 ); func (e Color) Name() string; func (e Color) String() string
+func (s *Scope) goEnum(cls *ast.ClassDef) *jen.Statement {
+	name := goId(cls.Name)
+	base := enumType(cls)
 
-	if c, ok := iter.(*ast.Call); ok { // check for "for x in range(n)"
-		//
-		// for x in range(y)
-		//
-		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "range" {
-			if len(c.Args) < 1 || len(c.Args) > 3 {
-				log.Printf("at %v:%v", iter.GetLineno(), iter.GetColOffset())
-				panic("range expects 1 to 3 arguments")
-			}
+	var consts, cases []jen.Code
+	next := 1
+	seen := map[string]bool{} // the member values, for the aliases
 
-			start := jen.Lit(0)
-			step := jen.Lit(1)
+	for _, a := range classVars(cls) {
+		member := a.Targets[0].(*ast.Name).Id
 
-			var stop jen.Code
+		var value *jen.Statement
 
-			if len(c.Args) == 1 {
-				stop = s.goExpr(c.Args[0])
-			} else {
-				start = s.goExpr(c.Args[0])
-				stop = s.goExpr(c.Args[1])
+		if call, ok := a.Value.(*ast.Call); ok && hasDecorator([]ast.Expr{call.Func}, "auto", "enum.auto") {
+			value = jen.Lit(next) // auto() is the previous value + 1
+		} else {
+			value = s.goExpr(a.Value)
 
-				if len(c.Args) > 2 {
-					step = s.goExpr(c.Args[2])
+			if n, ok := a.Value.(*ast.Num); ok {
+				if i, ok := n.N.(py.Int); ok {
+					next = int(i)
 				}
 			}
+		}
 
-			t := s.goExpr(target)
+		next++
 
-			return jen.For(t.Clone().Op(":=").Add(start),
-				t.Clone().Op("<").Add(stop),
-				t.Clone().Op("+=").Add(step)), nil
+		consts = append(consts, enumMember(cls, string(member)).Add(name.Clone()).Op("=").Add(value))
+
+		if v := value.GoString(); !seen[v] { // an alias has the name of the first member with the same value
+			seen[v] = true
+			cases = append(cases, jen.Case(enumMember(cls, string(member))).Block(jen.Return(jen.Lit(string(member)))))
 		}
+	}
 
-		//
-		// for i, v in enumerate(l)
-		//
-		if n, ok := c.Func.(*ast.Name); ok && string(n.Id) == "enumerate" && len(c.Args) == 1 {
-			return jen.For(s.goExprOrList(target).Op(":=").Range().Add(s.goExpr(c.Args[0]))), nil
+	recv := jen.Id("e")
+
+	return jen.Type().Add(name.Clone()).Add(goId(ast.Identifier(base))).Line().Line().
+		Const().Defs(consts...).Line().Line().
+		Func().Params(recv.Clone().Add(name.Clone())).Id("Name").Params().String().Block(
+		jen.Switch(recv.Clone()).Block(cases...),
+		jen.Return(jen.Lit("")),
+	).Line().Line().
+		Func().Params(recv.Clone().Add(name.Clone())).Id("String").Params().String().Block(
+		jen.If(jen.Id("n").Op(":=").Add(recv.Clone()).Dot("Name").Call(), jen.Id("n").Op("!=").Lit("")).Block(
+			jen.Return(jen.Lit(string(cls.Name)+".").Op("+").Id("n")),
+		),
+		jen.Return(jen.Qual("fmt", "Sprintf").Call(jen.Lit(string(cls.Name)+"(%v)"), goId(ast.Identifier(base)).Call(recv.Clone()))),
+	).Line()
+}
+
+// x = a if cond else b -> if cond { x = a } else { x = b }
+func (s *Scope) goIfExpAssign(assign *ast.Assign) *jen.Statement {
+	ifexp := assign.Value.(*ast.IfExp)
+	stmt := jen.Null()
+
+	if name, ok := assign.Targets[0].(*ast.Name); ok && s.newNames(assign.Targets) {
+		typ := goAny.Clone()
+		if t := s.exprType(ifexp.Body); t == s.exprType(ifexp.Orelse) {
+			typ = goBasicType(t)
+			s.setType(name.Id, t)
 		}
 
-		//
-		// for v in iterator
-		//
-		//if lenExpr(target) <= 1 {
-		//    return jen.For(jen.List(jen.Op("_"), s.goExpr(target)).Op(":=").Range().Add(s.goExpr(iter))), nil
-		//}
+		stmt.Var().Add(goVarId(name.Id)).Add(typ).Line()
 	}
 
-	// for x in iterable
-	// for k, v in dict
-	// for a,b,c in tuple iterable
+	body, orelse := *assign, *assign
+	body.Value, orelse.Value = ifexp.Body, ifexp.Orelse
 
-	switch lenExpr(target) {
-	case 0:
-		log.Fatalf("for without target: %#v", target)
+	return stmt.If(s.goCond(ifexp.Test)).Block(s.goAssignStmt(&body)).Else().Block(s.goAssignStmt(&orelse))
+}
 
-	case 1:
-		return jen.For(jen.List(jen.Op("_"), s.goExpr(target)).Op(":=").Range().Add(s.goExpr(iter))), nil
+// check if a top level statement is executable code, that needs to be in a function
+// (the declarations, the __main__ guard and the docstrings are at the package level)
+func isExecutable(stmt ast.Stmt) bool {
+	switch v := stmt.(type) {
+	case *ast.FunctionDef, *ast.ClassDef, *ast.Import, *ast.ImportFrom, *ast.Assign, *ast.Pass, *ast.Global:
+		return false
 
-	case 2:
-		return jen.For(s.goExprOrList(target).Op(":=").Range().Add(s.goExpr(iter))), nil
+	case *ast.ExprStmt:
+		_, doc := v.Value.(*ast.Str)
+		return !doc
 
-	default:
-		t := target.(*ast.Tuple)
-		return jen.For(jen.Id("_t").Commentf("/* %s */", s.strExprList(t.Elts)).Op(":=").Range().Add(s.goExpr(iter))), t.Elts
+	case *ast.If:
+		return !isNameMain(v.Test)
 	}
 
-	return nil, nil // shouldn't get here
+	return true
 }
 
-func (s *Scope) goAssign(assign *ast.Assign) (*jen.Statement, *jen.Statement, *jen.Statement) {
-	goType := goAny.Clone()
+// check if a top level assignment needs to be in a function: the targets are not all new names (or it
+// would be a new declaration), the value is a conditional expression (converted to an if statement),
+// or it follows other top level statements (that may change the values it depends on).
+func (s *Scope) isTopAssignment(stmt ast.Stmt) bool {
+	a, ok := stmt.(*ast.Assign)
+	if !ok || s.isConstant(a) {
+		return false
+	}
 
-	switch t := assign.Value.(type) {
-	case *ast.Tuple:
-		goType = goTuple.Clone()
+	if _, ok := a.Value.(*ast.IfExp); ok || s.initBody != nil {
+		return true
+	}
 
-	case *ast.List:
-		goType = goList.Clone()
+	for _, t := range a.Targets {
+		switch t.(type) {
+		case *ast.Name, *ast.Tuple:
+		default: // d[k] = v, obj.x = v
+			return true
+		}
+
+		for _, id := range exprIds(t) {
+			if s.isDefined(id) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// check if there is a `if __name__ == "__main__":` guard
+func hasMainGuard(body []ast.Stmt) bool {
+	for _, stmt := range body {
+		if v, ok := stmt.(*ast.If); ok && isNameMain(v.Test) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// move a top level statement to the init function, or to the main function for a script without a __main__ guard.
+// The names assigned in the statement are global in python, so they are declared as package variables.
+func (s *Scope) goInit(stmt ast.Stmt) {
+	walkBody([]ast.Stmt{stmt}, func(st ast.Stmt) {
+		a, ok := st.(*ast.Assign)
+		if !ok {
+			return
+		}
 
-	case *ast.Dict:
-		goType = goDict.Clone()
+		for _, t := range a.Targets {
+			for _, id := range exprIds(t) {
+				if s.isDefined(id) {
+					continue
+				}
 
-	case *ast.Str:
-		goType = jen.String()
+				typ := goAny.Clone()
+				if _, ok := t.(*ast.Name); ok {
+					s.setType(id, s.exprType(a.Value))
+					typ = goBasicType(s.exprType(a.Value))
+				}
 
-	case *ast.Num:
-		switch t.N.(type) {
-		case py.Int:
-			goType = jen.Int()
+				s.addName(id)
+				s.Add(jen.Line())
+				s.Add(jen.Var().Add(goVarId(id)).Add(typ))
+			}
+		}
+	})
 
-		case py.Float:
-			goType = jen.Float64()
+	ss := s.Push()
+	body := ss.parseBody("", []ast.Stmt{stmt})
+	ss.Pop(false)
 
-		case py.Complex:
-			goType = jen.Complex128()
+	if s.initBody == nil {
+		name := "init"
+		if s.script {
+			name = "main"
+			s.main = true
+			s.mainBody = body
 		}
+
+		s.initBody = body
+		s.Add(jen.Line())
+		s.Add(jen.Func().Id(name).Params().Block(body))
+	} else {
+		s.initBody.Add(jen.Line(), body)
 	}
+}
 
-	if len(assign.Targets) == 1 && (isTuple(assign.Targets[0]) || isList(assign.Targets[0])) {
-		return s.goExprOrList(assign.Targets[0]), s.goExprOrList(assign.Value), goType
+// convert `if __name__ == "__main__":` to the main function.
+// Multiple guards are merged into the same main function
+// and the else branch is left as top level code.
+func (s *Scope) goMain(v *ast.If) {
+	ss := s.Push()
+	body := ss.parseBody("", v.Body)
+	ss.Pop(false)
+
+	if s.mainBody == nil {
+		s.main = true
+		s.mainBody = body
+		s.Add(jen.Func().Id("main").Params().Block(body))
+	} else {
+		s.mainBody.Add(jen.Line(), jen.Comment(`if __name__ == "__main__"`), jen.Line(), body)
+		s.Add(jen.Comment(`if __name__ == "__main__": merged into main()`))
 	}
 
-	return s.goExpr(assign.Targets), s.goExpr(assign.Value), goType
+	if len(v.Orelse) > 0 { // parsed in a nested scope, so that it doesn't end up in init()
+		ss := s.Push()
+		orelse := ss.parseBody("", v.Orelse)
+		ss.Pop(false)
+
+		s.Add(jen.Line())
+		s.Add(jen.Comment(`if __name__ != "__main__": this is never executed in a main package`))
+		s.Add(jen.Line())
+		s.Add(jen.Comment(orelse.GoString()))
+	}
 }
 
 // parse a block/list of statements anre returns
@@ -1196,7 +4906,19 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 		log.Println("PARSE", s.level)
 	}
 
+	skip := 0 // statements already consumed by a previous one
+
 	for i, stmt := range body {
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		if s.Top() && (isExecutable(stmt) || s.isTopAssignment(stmt)) { // Go doesn't allow statements at the package level
+			s.goInit(stmt)
+			continue
+		}
+
 		if i > 0 {
 			s.Add(jen.Line())
 		}
@@ -1215,12 +4937,28 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 
 		switch v := stmt.(type) {
 		case *ast.ImportFrom:
+			if v.Module == "__future__" { // no Go equivalent
+				var names []string
+				for _, i := range v.Names {
+					names = append(names, string(i.Name))
+				}
+
+				s.Add(jen.Commentf("from __future__ import %v (ignored)", strings.Join(names, ", ")).Line())
+				break
+			}
+
 			s.imports[string(v.Module)] = string(v.Module)
 			for _, i := range v.Names {
 				if i.AsName != "" {
 					s.Add(jen.Commentf("import %v %q // %v", i.AsName, v.Module, i.Name).Line())
+					s.imports[string(i.AsName)] = string(v.Module)
+					s.members[string(i.AsName)] = string(i.Name)
 				} else {
 					s.Add(jen.Commentf("import %q // %v", v.Module, i.Name).Line())
+					if i.Name != "*" {
+						s.imports[string(i.Name)] = string(v.Module)
+						s.members[string(i.Name)] = string(i.Name)
+					}
 				}
 			}
 
@@ -1229,9 +4967,11 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 				if i.AsName != "" {
 					s.Add(jen.Commentf("import %s %q", i.AsName, i.Name).Line())
 					s.imports[string(i.AsName)] = string(i.Name)
+					delete(s.members, string(i.AsName))
 				} else {
 					s.Add(jen.Commentf("import %q", i.Name).Line())
 					s.imports[string(i.Name)] = string(i.Name)
+					delete(s.members, string(i.Name))
 				}
 			}
 
@@ -1243,11 +4983,16 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 				s.Add(jen.Commentf("// @%v\n", s.goExpr(d).GoString()))
 			}
 
+			if classname == "" {
+				s.funcs[string(v.Name)] = v
+			}
+
 			ss := s.Push()
 
 			arguments, recv := ss.goFunctionArguments(v.Args, classname != "")
 			if recv != nil {
 				receiver = jen.Params(goId(recv.Arg).Op("*").Id(classname))
+				ss.setType(recv.Arg, classname)
 			}
 			if v.Returns != nil && !isNone(v.Returns) {
 				returns = jen.Params(ss.goExprOrList(v.Returns))
@@ -1255,9 +5000,13 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 
 			stmt := jen.Func()
 			if receiver != nil {
-				if string(v.Name) == "__str__" {
-					stmt.Add(receiver).Id("String")
-					returns = jen.Params(jen.Id("string"))
+				if isSetter(v) {
+					stmt.Add(receiver).Id(setterName(v.Name))
+				} else if m, ok := specialMethods[string(v.Name)]; ok {
+					stmt.Add(receiver).Id(m.name)
+					if m.returns != "" {
+						returns = jen.Params(jen.Id(m.returns))
+					}
 				} else {
 					stmt.Add(receiver).Add(goId(v.Name))
 				}
@@ -1268,11 +5017,36 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 			}
 
 			ss.returnType = ReturnNone
+			ss.generator = isGenerator(v.Body)
+			ss.arity = returnArity(v)
+			ss.goDefaults(v.Args)
 			parsed := ss.parseBody("", v.Body)
-			if returns == nil && ss.returnType != ReturnNone {
+			if returns == nil && ss.arity > 1 { // return a, b -> (Any, Any)
+				returns = jen.ParamsFunc(func(g *jen.Group) {
+					for i := 0; i < ss.arity; i++ {
+						g.Add(goAny)
+					}
+				})
+			} else if returns == nil && ss.returnType != ReturnNone {
 				returns = goAny
 			}
 
+			if ss.generator {
+				// the generator body runs in a goroutine, sending the yielded values to the channel
+				returns = jen.Params(jen.Id("_c").Chan().Add(goAny))
+				gen := jen.Go().Func().Params().Block(jen.Defer().Close(jen.Id("_c")), parsed).Call()
+				parsed = jen.Id("_c").Op("=").Make(jen.Chan().Add(goAny)).Line().Add(gen).Line().Return()
+			}
+
+			if recv != nil && isCachedProperty(v) {
+				// compute the value only once and memoize it in the hidden field
+				field := goId(recv.Arg).Dot("_" + string(v.Name))
+				parsed = jen.If(field.Clone().Op("==").Nil()).Block(
+					jen.Id("v").Op(":=").Func().Params().Add(ss.propertyType(v)).Block(parsed).Call(),
+					field.Clone().Op("=").Op("&").Id("v"),
+				).Line().Return(jen.Op("*").Add(field.Clone()))
+			}
+
 			ss.Pop(true)
 
 			stmt.Params(arguments)
@@ -1302,6 +5076,25 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
                         // (and probably more)
                         //
 
+			s.classes[string(v.Name)] = v
+
+			// the class docstring is the doc comment for the type
+			var doc *ast.Str
+			if len(v.Body) > 0 {
+				if expr, ok := v.Body[0].(*ast.ExprStmt); ok {
+					doc, _ = expr.Value.(*ast.Str)
+				}
+			}
+
+			if isEnum(v) {
+				if doc != nil {
+					s.Add(jen.Comment(trimlines(doc.S)).Line())
+				}
+
+				s.Add(s.goEnum(v))
+				break
+			}
+
 			ss := s.Push()
 
 			classdef := jen.Type().Add(goId(v.Name)).StructFunc(func(g *jen.Group) {
@@ -1319,7 +5112,11 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 					g.Add(jen.Commentf("%v", cdefs))
 				}
 
-				for _, pst := range v.Body {
+				for i, pst := range v.Body {
+					if i == 0 && doc != nil {
+						continue
+					}
+
 					switch pv := pst.(type) {
 					case *ast.Pass:
 						continue
@@ -1335,7 +5132,21 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 						target, value, typ := s.goAssign(pv)
 						g.Add(target.Add(typ).Commentf("= %#v", value))
 
+					case *ast.ClassDef:
+						// a nested class is a separate type, named Outer_Inner
+						inner := *pv
+						inner.Name = v.Name + "_" + pv.Name
+						s.classes[string(v.Name)+"."+string(pv.Name)] = &inner
+						s.classes[string(inner.Name)] = &inner
+
+						s.methods = append(s.methods, ss.parseBody("", []ast.Stmt{&inner}))
+
 					case *ast.FunctionDef:
+						if isCachedProperty(pv) {
+							// hidden field for the memoized value
+							g.Add(jen.Id("_" + string(pv.Name)).Op("*").Add(ss.propertyType(pv)))
+						}
+
 						s.methods = append(s.methods,
 							ss.parseBody(string(v.Name), []ast.Stmt{pv}))
 
@@ -1343,44 +5154,161 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 						log.Fatalf("unexpected statement in class definition: %#v", pv)
 					}
 				}
+
+				// the instance attributes, assigned in the methods
+				names, values := instanceAttributes(v)
+				for i, name := range names {
+					typ := goAny.Clone()
+					if values[i] != nil {
+						typ = goValueType(values[i])
+					}
+
+					g.Id(rename(string(name))).Add(typ)
+				}
 			}).Line()
 
 			for _, d := range v.DecoratorList {
 				s.Add(jen.Commentf("@%v\n", s.goExpr(d).GoString()))
 			}
 
+			if doc != nil {
+				s.Add(jen.Comment(trimlines(doc.S)).Line())
+			}
+
 			s.Add(classdef)
+			s.Add(s.goConstructor(v))
+			if isDataclass(v) {
+				s.Add(s.goDataclassMethods(v))
+			}
 			ss.Pop(true) // after s.Add(classdef), to add the methods after the type definition
 
 		case *ast.Assign:
-			target, value, _ := s.goAssign(v)
-			stmt := target.Op("=").Add(value)
-			if s.newNames(v.Targets) {
-				stmt = jen.Var().Add(stmt)
+			if s.Top() && s.isConstant(v) {
+				// group consecutive constants in a single const block
+				consts := []jen.Code{s.goConstant(v)}
+				for _, next := range body[i+1:] {
+					if a, ok := next.(*ast.Assign); ok && s.isConstant(a) {
+						consts = append(consts, s.goConstant(a))
+						skip++
+					} else {
+						break
+					}
+				}
+
+				if len(consts) == 1 {
+					s.Add(jen.Const().Add(consts[0]))
+				} else {
+					s.Add(jen.Const().Defs(consts...))
+				}
+				break
+			}
+
+			if nt := s.goNamedTuple(v); nt != nil {
+				s.Add(nt)
+				break
+			}
+
+			if _, ok := v.Value.(*ast.IfExp); ok && len(v.Targets) == 1 {
+				s.Add(s.goIfExpAssign(v))
+				break
+			}
+
+			if len(v.Targets) > 1 {
+				// a = d[k] = value: assign the value to the first target
+				// and the first target to the others
+				first := *v
+				first.Targets = v.Targets[:1]
+				s.Add(s.goAssignStmt(&first))
+
+				for _, t := range v.Targets[1:] {
+					next := *v
+					next.Targets = []ast.Expr{t}
+					next.Value = v.Targets[0]
+					s.Add(jen.Line())
+					s.Add(s.goAssignStmt(&next))
+				}
+			} else {
+				s.Add(s.goAssignStmt(v))
 			}
-			s.Add(stmt)
 
 		case *ast.AugAssign:
-			s.Add(s.goExpr(v.Target).Add(s.goOpExt(v.Op, "=")).Add(s.goExpr(v.Value)))
+			switch v.Target.(type) {
+			case *ast.Subscript: // d[k] += v -> d[k] = runtime.Add(d[k], v), since the values are Any
+				target := s.goExpr(v.Target)
+				var value *jen.Statement
+				if op := runtimeOp(v.Op); op != "" {
+					value = jen.Qual(goRuntime, op).Call(target.Clone(), s.goExpr(v.Value))
+				} else {
+					value = target.Clone().Add(s.goOp(v.Op)).Add(s.goExpr(v.Value))
+				}
+
+				if set := s.goSetItem(v.Target.(*ast.Subscript), value); set != nil {
+					s.Add(set)
+				} else {
+					s.Add(target.Clone().Op("=").Add(value))
+				}
+
+			case *ast.Attribute: // obj.x += v -> obj.x = obj.x + v, or runtime.Add(obj.x, v) if not a known type
+				target := s.goExpr(v.Target)
+				op := runtimeOp(v.Op)
+				operand := s.goExpr(v.Value)
+
+				switch t := s.exprType(v.Target); t {
+				case "int", "float", "complex", "str":
+					op = ""
+					if s.exprType(v.Value) == "" { // self.count += n -> self.count = self.count + n.(int)
+						if _, ok := v.Value.(*ast.Name); !ok {
+							operand = jen.Parens(operand)
+						}
+						operand = operand.Assert(goBasicType(t))
+					}
+				}
+
+				var value *jen.Statement
+				if op != "" {
+					value = jen.Qual(goRuntime, op).Call(target.Clone(), operand)
+				} else {
+					value = target.Clone().Add(s.goOp(v.Op)).Add(operand)
+				}
+
+				if set := s.goSetProperty(v.Target.(*ast.Attribute), value); set != nil {
+					s.Add(set)
+				} else {
+					s.Add(target.Clone().Op("=").Add(value))
+				}
+
+			default:
+				if name, ok := v.Target.(*ast.Name); ok && v.Op == ast.Add {
+					if b := s.builder(name.Id); b != "" {
+						s.Add(jen.Id(b).Dot("WriteString").Call(s.goExpr(v.Value)).Commentf("%v += ...", name.Id))
+						break
+					}
+				}
+
+				stmt := s.goExpr(v.Target).Add(s.goOpExt(v.Op, "=")).Add(s.goExpr(v.Value))
+
+				if name, ok := v.Target.(*ast.Name); ok && s.newNames([]ast.Expr{name}) {
+					// not defined yet: the first occurrence is the initial value
+					s.setType(name.Id, s.exprType(v.Value))
+					stmt = jen.Var().Add(goVarId(name.Id)).Op("=").Add(s.goExpr(v.Value)).Comment(stmt.GoString())
+				}
+
+				s.Add(stmt)
+			}
 
 		case *ast.ExprStmt:
 			switch xStmt := v.Value.(type) {
 			case *ast.Yield:
-				ret := jen.Null()
+				ret := jen.Nil()
 				if xStmt.Value != nil {
-					ret = s.goExprOrList(xStmt.Value)
+					ret = s.goExpr(xStmt.Value)
 				}
-				//s.Add(jen.Commentf("yield %s", ret.GoString()))
-				s.Add(jen.Return(ret).Comment("yield"))
+				s.Add(jen.Id("_c").Op("<-").Add(ret).Comment("yield"))
 				s.returnType = ReturnYield
 
 			case *ast.YieldFrom:
-				ret := jen.Null()
-				if xStmt.Value != nil {
-					ret = s.goExprOrList(xStmt.Value)
-				}
-				//s.Add(jen.Commentf("yield from %s", ret.GoString()))
-				s.Add(jen.Return(ret).Comment("yield from"))
+				s.Add(jen.For(jen.List(jen.Op("_"), jen.Id("v")).Op(":=").Range().Add(s.goExpr(xStmt.Value))).Block(
+					jen.Id("_c").Op("<-").Id("v")).Comment("yield from"))
 				s.returnType = ReturnYield
 
 			default:
@@ -1399,39 +5327,49 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 		case *ast.Return:
 			if v.Value == nil {
 				s.Add(jen.Return())
-			} else {
+			} else if s.generator { // the return value of a generator is only available via StopIteration
+				s.Add(jen.Return().Commentf("return %v", s.goExpr(v.Value).GoString()))
+			} else if s.arity > 1 {
 				s.Add(jen.Return(s.goExprOrList(v.Value)))
+			} else {
+				s.Add(jen.Return(s.goExpr(v.Value)))
 			}
 			s.returnType = ReturnReturn
 
 		case *ast.If:
-			ss := s.Push()
-			stmt := jen.If(s.goExpr(v.Test))
-			if s.Top() && isNameMain(v.Test) && len(v.Orelse) == 0 {
-				stmt = jen.Func().Id("main").Params()
-				s.main = true
+			if s.Top() && isNameMain(v.Test) {
+				s.goMain(v)
+				break
 			}
+
+			ss := s.Push()
+			stmt := jen.If(s.goCond(v.Test))
 			stmt.Block(ss.parseBody("", v.Body))
-			if len(v.Orelse) > 0 {
-				if _, ok := v.Orelse[0].(*ast.If); ok && len(v.Orelse) == 1 {
-					stmt.Else().Add(ss.parseBody("", v.Orelse))
-				} else {
-					stmt.Else().Block(ss.parseBody("", v.Orelse))
+
+			// the elif chain is flattened to else if (instead of parsing the nested if statements)
+			orelse := v.Orelse
+			for len(orelse) == 1 {
+				elif, ok := orelse[0].(*ast.If)
+				if !ok {
+					break
 				}
+
+				stmt.Else().If(s.goCond(elif.Test)).Block(ss.parseBody("", elif.Body))
+				orelse = elif.Orelse
+			}
+
+			if len(orelse) > 0 {
+				stmt.Else().Block(ss.parseBody("", orelse))
 			}
 			ss.Pop(false)
 			s.Add(stmt)
 
 		case *ast.For:
+			builders := s.startBuilders(v, v.Body)
 			ss := s.Push()
-			stmt, targets := ss.goFor(v.Target, v.Iter)
-			assgn := jen.Null()
-			if targets != nil {
-				assgn = ss.goExprList(targets).Op(":=").ListFunc(func(g *jen.Group) {
-					for i := range targets {
-						g.Add(jen.Id("_t").Index(jen.Lit(i)))
-					}
-				})
+			stmt, assgn := ss.goFor(v.Target, v.Iter)
+			if assgn == nil {
+				assgn = jen.Null()
 			}
 			stmt.Block(assgn, ss.parseBody("", v.Body))
 			if len(v.Orelse) > 0 {
@@ -1439,10 +5377,12 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 			}
 			ss.Pop(false)
 			s.Add(stmt)
+			s.endBuilders(builders)
 
 		case *ast.While:
+			builders := s.startBuilders(v, v.Body)
 			ss := s.Push()
-			stmt := jen.For(ss.goExpr(v.Test))
+			stmt := jen.For(ss.goCond(v.Test))
 			if k, ok := v.Test.(*ast.NameConstant); ok && k.Value == py.True {
 				stmt = jen.For()
 			}
@@ -1452,6 +5392,7 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 			}
 			ss.Pop(false)
 			s.Add(stmt)
+			s.endBuilders(builders)
 
 		case *ast.Try:
 			ss := s.Push()
@@ -1471,7 +5412,14 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 					for _, h := range v.Handlers {
 						ch := jen.Case(ss.goExpr(h.ExprType))
 						if h.Name != "" {
-							ch.Block(jen.Commentf("as %v", h.Name), ss.parseBody("", h.Body))
+							// except E as e: e is the exception value
+							ss.addName(h.Name)
+							bind := goVarId(h.Name).Op(":=").Err()
+							if !usesName(h.Body, h.Name) {
+								bind.Line().Op("_").Op("=").Add(goVarId(h.Name))
+							}
+
+							ch.Block(jen.Commentf("as %v", h.Name), bind, ss.parseBody("", h.Body))
 						} else {
 							ch.Block(ss.parseBody("", h.Body))
 						}
@@ -1501,41 +5449,93 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 			s.Add(stmt)
 
 		case *ast.Assert:
-			if v.Msg != nil {
-				s.Add(goAssert.Call(s.goExpr(v.Test), s.goExpr(v.Msg)))
-			} else {
-				s.Add(goAssert.Call(s.goExpr(v.Test), jen.Lit("")))
-			}
+			s.Add(s.goAssertStmt(v))
 
 		case *ast.Global:
 			s.Add(jen.Commentf("global %v", s.strIdentifiers(v.Names)))
 
+			top := s.topScope()
+			for _, name := range v.Names {
+				s.addName(name) // assigning to the name doesn't declare a local variable
+				top.globals = append(top.globals, name)
+			}
+
 		case *ast.Nonlocal:
 			s.Add(jen.Commentf("nonlocal %v", s.strIdentifiers(v.Names)))
 
 		case *ast.Delete:
 			for _, t := range v.Targets {
-				if st, ok := t.(*ast.Subscript); ok {
-					if i, ok := st.Slice.(*ast.Index); ok {
-						s.Add(jen.Delete(s.goExpr(st.Value), s.goExpr(i.Value)))
+				switch dt := t.(type) {
+				case *ast.Subscript:
+					i, ok := dt.Slice.(*ast.Index)
+					if !ok {
+						log.Panicf("unexpected DELETE %#v", dt)
+					}
+
+					if s.exprType(dt.Value) == "list" { // del l[i] -> l = runtime.DelIndex(l, i)
+						l := s.goExpr(dt.Value)
+						s.Add(l.Clone().Op("=").Qual(goRuntime, "DelIndex").Call(l, s.goExpr(i.Value)))
 					} else {
-						log.Panicf("unexpected DELETE %#v", st)
+						s.Add(jen.Delete(s.goExpr(dt.Value), s.goExpr(i.Value)))
 					}
-				} else {
-					s.Add(jen.Comment(unknown("DELETE", t).GoString()))
+
+				case *ast.Name, *ast.Attribute:
+					// del x -> x = nil (or the zero value), so that the value can be garbage collected.
+					// Note that the name is still defined, and is not reported as undefined if used after
+					target := s.goExpr(t)
+					s.Add(target.Clone().Op("=").Add(zeroValue(s.exprType(t))).Commentf("del %v", target.GoString()))
+
+				default:
+					s.Add(jen.Commentf("del %v: not supported, remove the reference manually", s.goExpr(t).GoString()))
 				}
 			}
 
 		case *ast.With:
 			// We should really create an anonymous function
 			// with a defer (that we can't really fill, but in a few cases)
-			s.Add(jen.BlockFunc(func(g *jen.Group) {
+			deferred := false
+			jump := hasJump(v.Body)
+
+			with := jen.BlockFunc(func(g *jen.Group) {
 				ss := s.Push()
 				g.Comment("with")
 
-				for _, item := range v.Items {
-					if item.OptionalVars != nil {
-						g.Add(ss.goExpr(item.OptionalVars).Op(":=").Add(ss.goExpr(item.ContextExpr)))
+				for i, item := range v.Items {
+					if cm, d := ss.goContextManager(item); cm != nil {
+						deferred = deferred || d
+						g.Add(cm)
+					} else if !isOpen(item.ContextExpr) {
+						// v := runtime.Enter(ctx); defer runtime.Exit(ctx)
+						ctx := ss.goExpr(item.ContextExpr)
+						if _, ok := item.ContextExpr.(*ast.Name); !ok {
+							name := "_ctx"
+							if i > 0 {
+								name = fmt.Sprintf("_ctx%d", i)
+							}
+
+							g.Id(name).Op(":=").Add(ctx)
+							ctx = jen.Id(name)
+						}
+
+						enter := jen.Qual(goRuntime, "Enter").Call(ctx.Clone())
+						if item.OptionalVars != nil {
+							enter = ss.goExpr(item.OptionalVars).Op(":=").Add(enter)
+						}
+
+						g.Add(enter)
+						g.Defer().Qual(goRuntime, "Exit").Call(ctx.Clone())
+						deferred = true
+					} else if item.OptionalVars != nil { // the open fast path
+						if name, ok := item.OptionalVars.(*ast.Name); ok {
+							ss.setType(name.Id, ss.exprType(item.ContextExpr))
+						}
+
+						f := ss.goExpr(item.OptionalVars)
+						g.Add(f.Clone().Op(":=").Add(ss.goExpr(item.ContextExpr)))
+
+						// the files are closed in reverse order, when the function literal (or the function, for a jump) returns
+						g.Defer().Add(f.Clone()).Dot("Close").Call()
+						deferred = true
 					} else {
 						g.Add(ss.goExpr(item.ContextExpr))
 					}
@@ -1543,7 +5543,17 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 
 				g.Line().Add(ss.parseBody("", v.Body))
 				ss.Pop(false)
-			}))
+			})
+
+			if deferred && !jump { // the deferred calls need a function
+				s.Add(jen.Func().Params().Add(with).Call())
+			} else {
+				if deferred { // a break, continue or return can't jump out of a function literal
+					s.Add(jen.Comment("the deferred calls run when the function returns, not at the end of the with body"))
+				}
+
+				s.Add(with)
+			}
 
 		default:
 			s.Add(jen.Comment(unknown("STMT", stmt).GoString()))
@@ -1557,75 +5567,269 @@ func (s *Scope) parseBody(classname string, body []ast.Stmt) *jen.Statement {
 	return s.Render()
 }
 
+// convert the python source read from in to Go, writing it to out
+// (entry is false for the modules converted with -r, that are not the program entry point)
+func convert(in io.Reader, path, pname string, entry bool, out io.Writer, ignore bool) error {
+	tree, err := parser.Parse(in, path, "exec")
+	if err != nil {
+		return err
+	}
+
+	m, ok := tree.(*ast.Module)
+	if !ok {
+		return fmt.Errorf("expected Module, got %v", tree)
+	}
+
+	var doc *ast.Str
+
+	if len(m.Body) > 0 {
+		if expr, ok := m.Body[0].(*ast.ExprStmt); ok {
+			if str, ok := expr.Value.(*ast.Str); ok { // the module docstring
+				doc = str
+				m.Body = m.Body[1:]
+			}
+		}
+	}
+
+	f := jen.NewFile(pname)
+
+	scope := NewScope(f)
+	// only the entry point is a script, in the other modules the top level statements go in init()
+	scope.script = entry && !hasMainGuard(m.Body) && (pkgName == "" || pkgName == "main")
+	scope.module = m.Body
+	//scope.file.ImportAlias(goRuntime, ".")
+	scope.parseBody("", m.Body)
+
+	if globals := scope.goGlobals(); globals != nil {
+		// declared first, and rendered to add the imports
+		scope.Add(globals)
+		scope.Render()
+
+		n := len(scope.body) - 1
+		scope.body = append([]*jen.Statement{scope.body[n], jen.Line()}, scope.body[:n]...)
+	}
+
+	if scope.main && pkgName == "" && entry { // the other modules are in the package of their directory
+		pname = "main"
+	}
+
+	if buildConstraint != "" {
+		fmt.Fprintln(out, "//go:build", buildConstraint)
+		fmt.Fprintln(out)
+	}
+
+	fmt.Fprintln(out, "// generated by pygor")
+	if doc != nil {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, packageDoc(pname, doc.S))
+	}
+	fmt.Fprintln(out, "package", pname)
+	fmt.Fprintln(out)
+	scope.file.RenderImports(out)
+
+	stmts := append(scope.body, jen.Line())
+	scope.file.ImportAlias(goRuntime, ".")
+
+	for _, s := range stmts {
+		if err := s.Render(out); err != nil {
+			if ignore {
+				fmt.Fprintln(out, "ERROR:", err)
+			} else {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// convert a python file, writing the result to the mirrored path in outdir (or to stdout if outdir is empty).
+// When converting a directory (recursive) all the files in a directory are in the same package.
+func convertFile(path, root, outdir string, recursive, ignore bool) (err error) {
+	if !panicUnknown {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+	if debugLevel > 0 {
+		log.Printf(path, "-----------------\n")
+	}
+
+	var out io.Writer = os.Stdout
+
+	pkgdir := filepath.Dir(path) // the directory of the Go package
+
+	if outdir != "" {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(outdir, strings.TrimSuffix(rel, ".py")+".go")
+		pkgdir = filepath.Dir(target)
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		w, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+
+		defer w.Close()
+		out = w
+	}
+
+	pname := strings.TrimSuffix(filepath.Base(path), ".py")
+	if pkgName != "" {
+		pname = pkgName
+	} else if recursive {
+		pname = dirPackage(filepath.Dir(path), pkgdir)
+	}
+
+	entry := !recursive || filepath.Base(path) == "__main__.py"
+	return convert(in, path, pname, entry, out, ignore)
+}
+
+// return the package name for the files in a directory (srcdir, converted to pkgdir):
+// main if the directory has a __main__.py file, otherwise the name of the directory
+func dirPackage(srcdir, pkgdir string) string {
+	if _, err := os.Stat(filepath.Join(srcdir, "__main__.py")); err == nil {
+		return "main"
+	}
+
+	pname := filepath.Base(pkgdir)
+	if abs, err := filepath.Abs(pkgdir); err == nil {
+		pname = filepath.Base(abs) // for "." or ".."
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, pname)
+}
+
+// return the python files in the directory tree, skipping __pycache__ and dotfiles
+func walkSources(root string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := fi.Name()
+		if fi.IsDir() {
+			if path != root && (name == "__pycache__" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+		} else if !strings.HasPrefix(name, ".") && strings.HasSuffix(name, ".py") {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
 func main() {
 	flag.IntVar(&debugLevel, "d", debugLevel, "Parser debug level 0-4")
 	flag.BoolVar(&panicUnknown, "panic", panicUnknown, "panic on unknown expression, to get a stacktrace")
 	flag.BoolVar(&verbose, "verbose", verbose, "print statement and expressions")
 	flag.BoolVar(&lineno, "lines", lineno, "add source line numbers")
+	flag.BoolVar(&noAssert, "no-assert", noAssert, "remove assert statements (as python -O)")
+	flag.BoolVar(&useBuilder, "builder", useBuilder, "use a strings.Builder for string concatenation in loops (s += v)")
+	flag.StringVar(&renameSuffix, "suffix", renameSuffix, "suffix added to names that are reserved in Go")
+	symbols := flag.String("symbols", "", "file with python to Go symbol mappings (python-name go-package go-name)")
 
 	ignore := flag.Bool("ignore", false, "ignore errors")
+	flag.StringVar(&pkgName, "pkg", "", "package name (default the file name, or main when reading from stdin or for a __main__ script, or the directory name with -r)")
+	flag.StringVar(&buildConstraint, "build", "", "build constraint, added as a //go:build line")
+	recursive := flag.Bool("r", false, "convert all python files in the directories, recursively")
+	outdir := flag.String("o", "", "output directory (one .go file for each .py file)")
+	flag.BoolVar(recursive, "recursive", false, "same as -r")
 	flag.Parse()
 
 	parser.SetDebug(debugLevel)
 
-	if len(flag.Args()) == 0 {
-		log.Printf("Need files to parse")
-		os.Exit(1)
-	}
-
-	for _, path := range flag.Args() {
-		in, err := os.Open(path)
-		if err != nil {
+	if *symbols != "" {
+		if err := loadSymbols(*symbols); err != nil {
 			log.Fatal(err)
 		}
+	}
 
-		defer in.Close()
-		if debugLevel > 0 {
-			log.Printf(path, "-----------------\n")
-		}
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"} // read from stdin
+	}
 
-		fi, err := in.Stat()
-		if err != nil {
-			log.Fatal(err)
+	var failed, converted int
+
+	for _, path := range paths {
+		if path == "-" {
+			pname := "main"
+			if pkgName != "" {
+				pname = pkgName
+			}
+
+			if err := convert(os.Stdin, "<stdin>", pname, true, os.Stdout, *ignore); err != nil {
+				log.Fatal(err)
+			}
+
+			continue
 		}
 
-		tree, err := parser.Parse(in, path, "exec")
+		fi, err := os.Stat(path)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		m, ok := tree.(*ast.Module)
-		if !ok {
-			log.Fatal("expected Module, got", tree)
-		}
-
-		pname := strings.TrimSuffix(fi.Name(), ".py")
-		f := jen.NewFile(pname)
-
-		scope := NewScope(f)
-		//scope.file.ImportAlias(goRuntime, ".")
-		scope.parseBody("", m.Body)
+		if !fi.IsDir() {
+			if err := convertFile(path, filepath.Dir(path), *outdir, false, *ignore); err != nil {
+				log.Fatal(err)
+			}
 
-		if scope.main {
-			pname = "main"
+			continue
 		}
 
-		fmt.Println("// generated by pygor")
-		fmt.Println("package", pname)
-		fmt.Println()
-		scope.file.RenderImports(os.Stdout)
+		if !*recursive {
+			log.Fatalf("%v is a directory (use -r)", path)
+		}
 
-		stmts := append(scope.body, jen.Line())
-		scope.file.ImportAlias(goRuntime, ".")
+		files, err := walkSources(path)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-		for _, s := range stmts {
-			if err := s.Render(os.Stdout); err != nil {
-				if *ignore {
-					fmt.Println("ERROR:", err)
-				} else {
-					log.Fatal(err)
-				}
+		for _, f := range files {
+			if err := convertFile(f, path, *outdir, true, *ignore); err != nil {
+				log.Printf("FAIL %v: %v", f, err)
+				failed++
+			} else {
+				log.Printf("ok   %v", f)
+				converted++
 			}
 		}
 	}
+
+	if converted+failed > 0 { // directories
+		log.Printf("%v files converted, %v failed", converted, failed)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
 }
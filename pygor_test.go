@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// convert the python source and return the generated Go code
+func convertString(t *testing.T, src string) string {
+	var out bytes.Buffer
+
+	if err := convert(strings.NewReader(src), "test.py", "test", true, &out, false); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.String()
+}
+
+func TestPackageDoc(t *testing.T) {
+	doc := packageDoc("test", "A test module.\n\n    With a blank line.\n")
+	if expected := "// Package test: A test module.\n//\n// With a blank line."; doc != expected {
+		t.Errorf("expected %q, got %q", expected, doc)
+	}
+}
+
+func TestCallKwargs(t *testing.T) {
+	code := convertString(t, "def f(a, *args, **kw):\n    print(a, args, kw)\n\nf(1, 2, 3)\nf(1, 2, x=3)\n")
+
+	for _, expected := range []string{`f\(1, nil, 2, 3\)`, `f\(1, (runtime\.)?Dict\{"x": 3\}, 2\)`} {
+		if !regexp.MustCompile(expected).MatchString(code) {
+			t.Errorf("expected %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestCallKeywords(t *testing.T) {
+	code := convertString(t, `
+def search(items, *, key=None, reverse=False):
+    return sorted(items, key=key, reverse=reverse)
+
+def connect(host, *hosts, port=80, timeout):
+    print(host, hosts, port, timeout)
+
+search([3, 1, 2], reverse=True)
+connect("a", "b", "c", timeout=10)
+`)
+
+	for _, expected := range []string{`search\(.*, nil, true\)`, `connect\("a", 80, 10, "b", "c"\)`} {
+		if !regexp.MustCompile(expected).MatchString(code) {
+			t.Errorf("expected %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestInstanceAttributeTypes(t *testing.T) {
+	code := convertString(t, "class Counter:\n    def __init__(self):\n        self.count = 0\n\n    def add(self, n):\n        self.count += n\n")
+
+	if expected := `self.count = self.count + n.(int)`; !strings.Contains(code, expected) {
+		t.Errorf("expected %q in:\n%s", expected, code)
+	}
+}
+
+func TestForUnpackTuples(t *testing.T) {
+	for _, test := range []struct {
+		src      string
+		expected []string
+	}{
+		{
+			src: "d = {'a': 1}\nfor k, v in sorted(d.items()):\n    print(k, v)\n",
+			expected: []string{
+				`Sorted\((runtime\.)?Items\(d\), nil, false\)`,
+				`k, v := _t\.\((runtime\.)?Tuple\)\[0\], _t\.\((runtime\.)?Tuple\)\[1\]`,
+			},
+		},
+		{
+			src: "d = {'a': 1}\nfor k, v in sorted(d.items(), key=lambda kv: kv[1]):\n    print(k, v)\n",
+			expected: []string{
+				`k, v := _t\.\((runtime\.)?Tuple\)\[0\], _t\.\((runtime\.)?Tuple\)\[1\]`,
+			},
+		},
+		{
+			src: "def f(x, y):\n    for a, b in zip(x(), y()):\n        print(a, b)\n",
+			expected: []string{
+				`a, b := _t\.\((runtime\.)?Tuple\)\[0\], _t\.\((runtime\.)?Tuple\)\[1\]`,
+			},
+		},
+	} {
+		code := convertString(t, test.src)
+
+		for _, expected := range test.expected {
+			if !regexp.MustCompile(expected).MatchString(code) {
+				t.Errorf("expected %q in:\n%s", expected, code)
+			}
+		}
+	}
+}
+
+func TestLambdaDefaults(t *testing.T) {
+	code := convertString(t, "r = 3\nscale = lambda x, r=r: x * r\n")
+
+	for _, expected := range []string{`_r := r`, `if r == nil \{\s*r = _r\s*\}`, `func\(x (runtime\.)?Any, r (runtime\.)?Any`} {
+		if !regexp.MustCompile(expected).MatchString(code) {
+			t.Errorf("expected %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestZipIndexed(t *testing.T) {
+	for _, test := range []struct {
+		src      string
+		expected string
+	}{
+		{
+			src:      "x = [1, 2]\ny = [3, 4]\nfor a, b in zip(x, y):\n    print(a, b)\n",
+			expected: `a, b := x\[_i\], y\[_i\]`,
+		},
+		{
+			src:      "def f(x, y):\n    for a, b in zip(x, y):\n        print(a, b)\n",
+			expected: `a, b := _t\.\((runtime\.)?Tuple\)\[0\], _t\.\((runtime\.)?Tuple\)\[1\]`,
+		},
+	} {
+		code := convertString(t, test.src)
+
+		if !regexp.MustCompile(test.expected).MatchString(code) {
+			t.Errorf("expected %q in:\n%s", test.expected, code)
+		}
+	}
+}
+
+func TestStrIndex(t *testing.T) {
+	code := convertString(t, "def f(l):\n    return l.index('a')\n\ndef g():\n    return 'abc'.index('b')\n")
+
+	if strings.Contains(code, "StrIndex(l") {
+		t.Errorf("unexpected StrIndex for a non-string in:\n%s", code)
+	}
+	if expected := `StrIndex\("abc", "b"\)`; !regexp.MustCompile(expected).MatchString(code) {
+		t.Errorf("expected %q in:\n%s", expected, code)
+	}
+}
+
+func TestFromImport(t *testing.T) {
+	code := convertString(t, "from datetime import datetime\nfrom os import getcwd\n\nprint(datetime.now(), getcwd())\n")
+
+	for _, expected := range []string{`datetime\.datetime\.now\(\)`, `os\.Getwd\(\)`} {
+		if !regexp.MustCompile(expected).MatchString(code) {
+			t.Errorf("expected %q in:\n%s", expected, code)
+		}
+	}
+}
+
+func TestEnumAlias(t *testing.T) {
+	code := convertString(t, "from enum import Enum\n\nclass Status(Enum):\n    ACTIVE = 1\n    RUNNING = 1\n")
+
+	if n := strings.Count(code, "case Status"); n != 1 {
+		t.Errorf("expected 1 case, got %v in:\n%s", n, code)
+	}
+	if expected := `return "Status." + n`; !strings.Contains(code, expected) {
+		t.Errorf("expected %q in:\n%s", expected, code)
+	}
+}
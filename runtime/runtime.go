@@ -1,21 +1,129 @@
 package runtime
 
+import "bytes"
+import "encoding/json"
 import "fmt"
+import "log"
+import "math"
+import "math/bits"
+import "math/rand"
+import "os"
+import "path/filepath"
+import "reflect"
 import "regexp"
+import "sort"
+import "strconv"
 import "strings"
+import "time"
 import "unicode"
+import "unicode/utf8"
 
 type Any = interface{}
 type Dict = map[string]Any
 type List = []Any
 type Tuple = []Any
+type Set = map[Any]struct{}
 
 //
-// Assert that the condition is true
+// Assert that the condition is true (line is the source line of the assertion)
 //
-func Assert(cond bool, message string) {
+func Assert(cond bool, line int, message string) {
 	if !cond {
-		panic("AssertionError: " + message)
+		panic(assertionError(line, message))
+	}
+}
+
+// the assertion error: "AssertionError: line n" or "AssertionError: line n: message"
+func assertionError(line int, message string) string {
+	err := "AssertionError: line " + strconv.Itoa(line)
+	if message != "" {
+		err += ": " + message
+	}
+
+	return err
+}
+
+//
+// Check if a and b are equal (a == b), comparing containers by value and numbers of different types by value
+//
+func Equal(a, b Any) bool {
+	switch x := a.(type) {
+	case int:
+		if y, ok := b.(float64); ok {
+			return float64(x) == y
+		}
+
+	case float64:
+		if y, ok := b.(int); ok {
+			return x == float64(y)
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+//
+// Assert that a and b are equal (unittest assertEqual)
+//
+func AssertEqual(a, b Any, line int, message string) {
+	if !Equal(a, b) {
+		panic(assertionError(line, message) + ": " + Repr(a) + " != " + Repr(b))
+	}
+}
+
+//
+// Assert that the comparison a op b is true (assert a < b), where op is one of
+// ==, !=, <, <=, >, >=, in, not in. The message includes the values of the operands.
+//
+func AssertCmp(a Any, op string, b Any, line int, message string) {
+	var ok bool
+
+	switch op {
+	case "==":
+		ok = Equal(a, b)
+	case "!=":
+		ok = !Equal(a, b)
+	case "<":
+		ok = Less(a, b)
+	case "<=":
+		ok = !Less(b, a)
+	case ">":
+		ok = Less(b, a)
+	case ">=":
+		ok = !Less(a, b)
+	case "in":
+		ok = Contains(b, a)
+	case "not in":
+		ok = !Contains(b, a)
+	default:
+		panic("AssertCmp: unknown operator " + op)
+	}
+
+	if !ok {
+		panic(assertionError(line, message) + ": assert " + Repr(a) + " " + op + " " + Repr(b))
+	}
+}
+
+//
+// Assert that f raises one of the exceptions (unittest assertRaises)
+//
+func AssertRaises(f func(), exceptions ...string) {
+	defer Raises(exceptions...)
+	f()
+}
+
+//
+// Check that the function raised one of the exceptions, and re-panic on anything else
+// (with self.assertRaises(...)). It must be called with defer.
+//
+func Raises(exceptions ...string) {
+	r := recover()
+	if r == nil {
+		panic("AssertionError: " + strings.Join(exceptions, ", ") + " not raised")
+	}
+
+	if !IsException(r, exceptions...) {
+		panic(r)
 	}
 }
 
@@ -41,6 +149,13 @@ func Contains(bag, value interface{}) bool {
 		if s, ok := value.(string); ok {
 			return strings.Contains(c, s)
 		}
+
+	case *DefaultDict:
+		return Contains(c.Dict, value)
+
+	case Set:
+		_, ok := c[value]
+		return ok
 	}
 
 	return false
@@ -60,6 +175,13 @@ func (e *PyException) Error() string {
 	return fmt.Sprintf("PyException(%v)", e.exc)
 }
 
+//
+// The exception value as a string, as str(e)
+//
+func (e PyException) String() string {
+	return Str(e.exc)
+}
+
 //
 // An error generated by "raise"
 //
@@ -67,6 +189,112 @@ func RaisedException(exc interface{}) PyException {
 	return PyException{exc: exc}
 }
 
+//
+// The exception raised by Next when the generator is exhausted
+//
+type StopIteration struct{}
+
+//
+// Return the next value from a generator (next(gen) or next(gen, default)).
+// If the generator is exhausted it returns the default value, if given,
+// or raises (panics with) StopIteration.
+//
+func Next(gen chan Any, def ...Any) Any {
+	v, ok := <-gen
+	if !ok {
+		if len(def) > 0 {
+			return def[0]
+		}
+
+		panic(RaisedException(StopIteration{}))
+	}
+
+	return v
+}
+
+//
+// Check if the recovered value r matches one of the python exception names
+//
+func IsException(r interface{}, exceptions ...string) bool {
+	err, _ := r.(error)
+
+	var exc interface{}
+
+	switch e := r.(type) {
+	case PyException:
+		exc = e.exc
+	case *PyException:
+		exc = e.exc
+	}
+
+	for _, name := range exceptions {
+		switch name {
+		case "Exception", "BaseException":
+			return true
+
+		case "FileNotFoundError":
+			if err != nil && os.IsNotExist(err) {
+				return true
+			}
+
+		case "FileExistsError":
+			if err != nil && os.IsExist(err) {
+				return true
+			}
+
+		case "PermissionError":
+			if err != nil && os.IsPermission(err) {
+				return true
+			}
+		}
+
+		if exc != nil && reflect.TypeOf(exc).Name() == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Enter the runtime context of a context manager (with ctx as v), returning the value bound to v.
+// This calls ctx.Enter() (python __enter__) if available, otherwise it returns ctx.
+//
+func Enter(ctx Any) Any {
+	if cm, ok := ctx.(interface{ Enter() Any }); ok {
+		return cm.Enter()
+	}
+
+	return ctx
+}
+
+//
+// Exit the runtime context of a context manager, calling ctx.Exit(nil, nil, nil) (python __exit__)
+// if available, otherwise ctx.Close()
+//
+func Exit(ctx Any) {
+	switch cm := ctx.(type) {
+	case interface{ Exit(Any, Any, Any) Any }:
+		cm.Exit(nil, nil, nil)
+
+	case interface{ Exit(Any, Any, Any) }:
+		cm.Exit(nil, nil, nil)
+
+	case interface{ Close() error }:
+		cm.Close()
+	}
+}
+
+//
+// Recover from a panic matching one of the exception names and re-panic on anything else
+// (with contextlib.suppress(...)). It must be called with defer.
+//
+func Suppress(exceptions ...string) {
+	if r := recover(); r != nil && !IsException(r, exceptions...) {
+		panic(r)
+	}
+}
+
 //
 // The string contains only whitespace characters
 //
@@ -173,3 +401,1759 @@ func Reverse(l List) {
 		right -= 1
 	}
 }
+
+//
+// Remove the element at index i (negative values count from the end) and return the list, as `del l[i]`
+//
+func DelIndex(l List, i int) List {
+	if i < 0 {
+		i += len(l)
+	}
+
+	if i < 0 || i >= len(l) {
+		panic("IndexError: list assignment index out of range")
+	}
+
+	return append(l[:i], l[i+1:]...)
+}
+
+//
+// Integer power (x ** y), for y >= 0 (with a negative exponent the result is a float, use math.Pow)
+//
+func IntPow(x, y int) int {
+	if y < 0 {
+		panic("ValueError: negative exponent in integer power")
+	}
+
+	res := 1
+
+	for y > 0 {
+		if y&1 != 0 {
+			res *= x
+		}
+		x *= x
+		y >>= 1
+	}
+
+	return res
+}
+
+//
+// Modular exponentiation (pow(base, exp, mod)), for exp >= 0.
+// The intermediate products are 128 bits, so that they don't overflow for large moduli
+//
+func ModPow(base, exp, mod int) int {
+	if mod == 0 {
+		panic("ValueError: pow() 3rd argument cannot be 0")
+	}
+
+	if exp < 0 {
+		panic("ValueError: pow() 2nd argument cannot be negative when 3rd argument specified")
+	}
+
+	m := mod
+	if m < 0 {
+		m = -m
+	}
+
+	b := base % m
+	if b < 0 {
+		b += m
+	}
+
+	res, x := uint64(1%m), uint64(b)
+
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 != 0 {
+			res = mulMod(res, x, uint64(m))
+		}
+		x = mulMod(x, x, uint64(m))
+	}
+
+	// the result has the same sign as mod, as in Python
+	if mod < 0 && res != 0 {
+		return int(res) - m
+	}
+
+	return int(res)
+}
+
+// (a * b) % m, for a, b < m
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, rem := bits.Div64(hi, lo, m)
+	return rem
+}
+
+//
+// Replace the elements of the list between start and end with values (l[start:end] = values)
+//
+func SetSlice(l *List, start, end int, values List) {
+	n := len(*l)
+
+	if start < 0 {
+		start = 0
+	} else if start > n {
+		start = n
+	}
+
+	if end < start {
+		end = start
+	} else if end > n {
+		end = n
+	}
+
+	res := make(List, 0, n-(end-start)+len(values))
+	res = append(res, (*l)[:start]...)
+	res = append(res, values...)
+	res = append(res, (*l)[end:]...)
+	*l = res
+}
+
+//
+// Convert a value to string, as Python str()
+//
+func Str(v Any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+
+	case fmt.Stringer: // __str__
+		return t.String()
+	}
+
+	return Repr(v)
+}
+
+//
+// Convert a value to its representation, as Python repr()
+//
+func Repr(v Any) string {
+	switch t := v.(type) {
+	case nil:
+		return "None"
+
+	case bool:
+		if t {
+			return "True"
+		}
+		return "False"
+
+	case string:
+		q := "'"
+		if strings.Contains(t, "'") && !strings.Contains(t, "\"") {
+			q = "\""
+		}
+
+		r := strings.NewReplacer("\\", "\\\\", q, "\\"+q, "\n", "\\n", "\r", "\\r", "\t", "\\t")
+		return q + r.Replace(t) + q
+
+	case float64:
+		s := strconv.FormatFloat(t, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+
+	case List: // or Tuple
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = Repr(e)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+
+	case Dict:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys) // Go maps are not ordered
+
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = Repr(k) + ": " + Repr(t[k])
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+
+	case Set:
+		if len(t) == 0 {
+			return "set()"
+		}
+
+		elems := ToList(t)
+		parts := make([]string, len(elems))
+		for i, e := range elems {
+			parts[i] = Repr(e)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+
+	case *DefaultDict:
+		return "defaultdict(" + Repr(t.Dict) + ")"
+
+	case fmt.GoStringer: // __repr__
+		return t.GoString()
+
+	case fmt.Stringer:
+		return t.String()
+	}
+
+	return fmt.Sprint(v)
+}
+
+func toFloat(v Any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+
+	case float64:
+		return n, true
+	}
+
+	return 0, false
+}
+
+func typeError(op string, a, b Any) string {
+	return fmt.Sprintf("TypeError: unsupported operand type(s) for %v: '%T' and '%T'", op, a, b)
+}
+
+//
+// Apply a numeric operation, promoting the operands to float64 if they are not both int
+//
+func numeric(op string, a, b Any, iop func(x, y int) int, fop func(x, y float64) float64) Any {
+	if x, ok := a.(int); ok && iop != nil {
+		if y, ok := b.(int); ok {
+			return iop(x, y)
+		}
+	}
+
+	x, okx := toFloat(a)
+	y, oky := toFloat(b)
+	if okx && oky {
+		return fop(x, y)
+	}
+
+	panic(typeError(op, a, b))
+}
+
+//
+// a + b (numbers, strings or lists)
+//
+func Add(a, b Any) Any {
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return x + y
+		}
+
+	case List: // or Tuple
+		if y, ok := b.(List); ok {
+			return append(append(List{}, x...), y...)
+		}
+	}
+
+	return numeric("+", a, b,
+		func(x, y int) int { return x + y },
+		func(x, y float64) float64 { return x + y })
+}
+
+//
+// a - b
+//
+func Sub(a, b Any) Any {
+	return numeric("-", a, b,
+		func(x, y int) int { return x - y },
+		func(x, y float64) float64 { return x - y })
+}
+
+//
+// a * b (numbers, or string repetition)
+//
+func Mul(a, b Any) Any {
+	if x, ok := a.(string); ok {
+		if n, ok := b.(int); ok {
+			if n < 0 {
+				n = 0
+			}
+			return strings.Repeat(x, n)
+		}
+	}
+
+	return numeric("*", a, b,
+		func(x, y int) int { return x * y },
+		func(x, y float64) float64 { return x * y })
+}
+
+//
+// a / b (always a float, as in Python 3)
+//
+func Div(a, b Any) Any {
+	return numeric("/", a, b, nil, func(x, y float64) float64 {
+		if y == 0 {
+			panic("ZeroDivisionError: division by zero")
+		}
+		return x / y
+	})
+}
+
+//
+// a // b (rounded towards negative infinity)
+//
+func FloorDiv(a, b Any) Any {
+	return numeric("//", a, b,
+		func(x, y int) int {
+			if y == 0 {
+				panic("ZeroDivisionError: integer division or modulo by zero")
+			}
+			q := x / y
+			if (x%y != 0) && ((x < 0) != (y < 0)) {
+				q--
+			}
+			return q
+		},
+		func(x, y float64) float64 {
+			if y == 0 {
+				panic("ZeroDivisionError: float divmod()")
+			}
+			return math.Floor(x / y)
+		})
+}
+
+//
+// a % b (the result has the same sign as b)
+//
+func Mod(a, b Any) Any {
+	return numeric("%", a, b,
+		func(x, y int) int {
+			if y == 0 {
+				panic("ZeroDivisionError: integer division or modulo by zero")
+			}
+			m := x % y
+			if m != 0 && ((m < 0) != (y < 0)) {
+				m += y
+			}
+			return m
+		},
+		func(x, y float64) float64 {
+			if y == 0 {
+				panic("ZeroDivisionError: float modulo")
+			}
+			m := math.Mod(x, y)
+			if m != 0 && ((m < 0) != (y < 0)) {
+				m += y
+			}
+			return m
+		})
+}
+
+//
+// Return the value of the environment variable, or def if not set (os.environ.get(key, def))
+//
+func Getenv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+
+	return def
+}
+
+//
+// Add all the entries of other to d (d.update(other))
+//
+func Update(d, other Dict) {
+	for k, v := range other {
+		d[k] = v
+	}
+}
+
+//
+// Remove key from d and return its value (d.pop(key[, def])).
+// If the key is not found return def, or panic if def is not specified.
+//
+func DictPop(d Dict, key string, def ...Any) Any {
+	if v, ok := d[key]; ok {
+		delete(d, key)
+		return v
+	}
+
+	if len(def) > 0 {
+		return def[0]
+	}
+
+	panic("KeyError: " + key)
+}
+
+//
+// Return the value for key, setting it to def if not present (d.setdefault(key, def))
+//
+func SetDefault(d Dict, key string, def Any) Any {
+	if v, ok := d[key]; ok {
+		return v
+	}
+
+	d[key] = def
+	return def
+}
+
+//
+// Python truthiness: None, False, zero, empty strings and empty collections are false
+//
+func Truthy(v Any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+
+	case bool:
+		return t
+
+	case int:
+		return t != 0
+
+	case float64:
+		return t != 0
+
+	case complex128:
+		return t != 0
+
+	case string:
+		return len(t) > 0
+
+	case List: // or Tuple
+		return len(t) > 0
+
+	case Dict:
+		return len(t) > 0
+
+	case interface{ Len() int }:
+		return t.Len() > 0
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Chan:
+		return rv.Len() > 0
+
+	case reflect.Ptr, reflect.Interface, reflect.Func:
+		return !rv.IsNil()
+	}
+
+	return true
+}
+
+//
+// Call the function f with the list of arguments (f(*args)).
+// Return nil if the function doesn't return a value, the result value
+// or a Tuple if the function returns multiple values.
+//
+func Apply(f Any, args List) Any {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+
+	in := make([]reflect.Value, len(args))
+	for i, a := range args {
+		var pt reflect.Type
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			pt = ft.In(ft.NumIn() - 1).Elem()
+		} else if i < ft.NumIn() {
+			pt = ft.In(i)
+		}
+
+		if a == nil && pt != nil {
+			in[i] = reflect.Zero(pt)
+		} else {
+			in[i] = reflect.ValueOf(a)
+		}
+	}
+
+	out := fv.Call(in)
+
+	switch len(out) {
+	case 0:
+		return nil
+
+	case 1:
+		return out[0].Interface()
+	}
+
+	res := make(Tuple, len(out))
+	for i, o := range out {
+		res[i] = o.Interface()
+	}
+
+	return res
+}
+
+//
+// Insert the values in the argument list at position i, or at the end if the list is shorter
+// (the arguments for Apply, when the **kwargs map comes after the positional values from *args)
+//
+func InsertArgs(args List, i int, values ...Any) List {
+	if i > len(args) {
+		i = len(args)
+	}
+
+	res := make(List, 0, len(args)+len(values))
+	res = append(res, args[:i]...)
+	res = append(res, values...)
+	return append(res, args[i:]...)
+}
+
+//
+// Return a new Dict with the entries of all the dicts (later values override earlier ones)
+//
+func MergeDicts(dicts ...Dict) Dict {
+	res := Dict{}
+
+	for _, d := range dicts {
+		for k, v := range d {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+//
+// A dict that initializes missing keys with the value returned by the factory function (collections.defaultdict)
+//
+type DefaultDict struct {
+	Dict
+	Factory func() Any
+}
+
+//
+// Create a new DefaultDict (defaultdict(factory))
+//
+func NewDefaultDict(factory func() Any) *DefaultDict {
+	return &DefaultDict{Dict: Dict{}, Factory: factory}
+}
+
+//
+// Return the value for key, initializing it if missing (d[key])
+//
+func (d *DefaultDict) Get(key string) Any {
+	v, ok := d.Dict[key]
+	if !ok {
+		if d.Factory == nil {
+			panic("KeyError: " + key)
+		}
+
+		v = d.Factory()
+		d.Dict[key] = v
+	}
+
+	return v
+}
+
+//
+// Set the value for key (d[key] = value)
+//
+func (d *DefaultDict) Set(key string, value Any) {
+	d.Dict[key] = value
+}
+
+//
+// Return the number of entries (len(d))
+//
+func (d *DefaultDict) Len() int {
+	return len(d.Dict)
+}
+
+//
+// Return the length of a string, a collection or an object implementing Len() (len(v))
+//
+func Len(v Any) int {
+	switch t := v.(type) {
+	case string:
+		return len(t)
+
+	case interface{ Len() int }:
+		return t.Len()
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Chan, reflect.String:
+		return rv.Len()
+	}
+
+	panic(fmt.Sprintf("TypeError: object of type '%T' has no len()", v))
+}
+
+//
+// Compare two values as Python a < b (numbers, strings and lists)
+//
+func Less(a, b Any) bool {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return x < y
+		}
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return x < y
+		}
+
+	case List: // or Tuple, compared lexicographically
+		if y, ok := b.(List); ok {
+			for i := 0; i < len(x) && i < len(y); i++ {
+				if Less(x[i], y[i]) {
+					return true
+				}
+				if Less(y[i], x[i]) {
+					return false
+				}
+			}
+
+			return len(x) < len(y)
+		}
+	}
+
+	panic(typeError("<", a, b))
+}
+
+//
+// Return a new sorted list of the elements of an iterable (sorted(v, key=key, reverse=reverse)),
+// as returned by ToList (i.e. the keys of a dict, the characters of a string).
+// key is an optional function of one argument, used to extract the comparison key from each element.
+// The sort is stable, also when reversed.
+//
+func Sorted(v Any, key Any, reverse bool) List {
+	l := ToList(v)
+
+	keys := l
+	if key != nil {
+		keys = make(List, len(l))
+		for i, v := range l {
+			keys[i] = Apply(key, List{v})
+		}
+	}
+
+	idx := make([]int, len(l))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		if reverse {
+			return Less(keys[idx[j]], keys[idx[i]])
+		}
+
+		return Less(keys[idx[i]], keys[idx[j]])
+	})
+
+	res := make(List, len(l))
+	for i, n := range idx {
+		res[i] = l[n]
+	}
+
+	return res
+}
+
+//
+// Return the (key, value) pairs of a dict as a list of tuples, sorted by key (d.items()).
+//
+func Items(d Dict) List {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := make(List, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, Tuple{k, d[k]})
+	}
+
+	return res
+}
+
+//
+// Return the elements of an iterable (list, tuple, string, dict, set or generator) as a new list (list(v)).
+// The keys of a dict and the elements of a set are sorted, since Go maps are not ordered.
+//
+func ToList(v Any) List {
+	res := List{}
+
+	switch t := v.(type) {
+	case nil:
+		// empty
+
+	case List: // or Tuple
+		res = append(res, t...)
+
+	case string:
+		for _, r := range t {
+			res = append(res, string(r))
+		}
+
+	case Dict:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			res = append(res, k)
+		}
+
+	case *DefaultDict:
+		return ToList(t.Dict)
+
+	case Set:
+		for e := range t {
+			res = append(res, e)
+		}
+
+		sort.Slice(res, func(i, j int) bool { return Repr(res[i]) < Repr(res[j]) })
+
+	case chan Any: // generator
+		for e := range t {
+			res = append(res, e)
+		}
+
+	default:
+		panic(fmt.Sprintf("TypeError: '%T' object is not iterable", v))
+	}
+
+	return res
+}
+
+//
+// Return the elements of an iterable as a new tuple (tuple(v))
+//
+func ToTuple(v Any) Tuple {
+	return ToList(v)
+}
+
+//
+// Return the values as a tuple, to collect the results of a function returning multiple values (x = f())
+//
+func MakeTuple(values ...Any) Tuple {
+	return append(Tuple{}, values...)
+}
+
+//
+// Return the elements of an iterable as a new set (set(v))
+//
+func ToSet(v Any) Set {
+	res := Set{}
+
+	for _, e := range ToList(v) {
+		res[e] = struct{}{}
+	}
+
+	return res
+}
+
+//
+// Return a new dict from a dict or an iterable of key/value pairs (dict(v))
+//
+func ToDict(v Any) Dict {
+	res := Dict{}
+
+	switch t := v.(type) {
+	case Dict:
+		return MergeDicts(t)
+
+	case *DefaultDict:
+		return MergeDicts(t.Dict)
+	}
+
+	for i, e := range ToList(v) {
+		pair := ToList(e)
+		if len(pair) != 2 {
+			panic(fmt.Sprintf("ValueError: dictionary update sequence element #%d has length %d; 2 is required", i, len(pair)))
+		}
+
+		k, ok := pair[0].(string)
+		if !ok {
+			panic(fmt.Sprintf("TypeError: unsupported key type '%T'", pair[0]))
+		}
+
+		res[k] = pair[1]
+	}
+
+	return res
+}
+
+//
+// Return a new list with the elements of the iterable in reverse order (reversed(v))
+//
+func Reversed(v Any) List {
+	l := ToList(v)
+	Reverse(l)
+	return l
+}
+
+//
+// Search sub in s[start:end] with the index function (strings.Index or strings.LastIndex).
+// The positions are in runes, as in StrSlice, and negative values are relative to the end of the string.
+// Return the rune position of sub in s, or -1 if not found
+//
+func strIndex(s, sub string, bounds []int, index func(string, string) int) int {
+	r := []rune(s)
+
+	start, end := sliceBounds(len(r), bounds)
+	if start > end {
+		return -1
+	}
+
+	str := string(r[start:end])
+	if i := index(str, sub); i >= 0 {
+		return start + utf8.RuneCountInString(str[:i])
+	}
+
+	return -1
+}
+
+// the start and end positions in a sequence of length n, as in strBounds
+func sliceBounds(n int, bounds []int) (int, int) {
+	pos := func(i int) int {
+		if i < 0 {
+			i += n
+			if i < 0 {
+				i = 0
+			}
+		} else if i > n {
+			i = n
+		}
+		return i
+	}
+
+	start, end := 0, n
+	if len(bounds) > 0 {
+		start = pos(bounds[0])
+	}
+	if len(bounds) > 1 {
+		end = pos(bounds[1])
+	}
+
+	return start, end
+}
+
+//
+// Return the substring s[start:end], where the positions are in runes (not bytes)
+// and negative values are relative to the end of the string. If end is missing it's the end of the string
+//
+func StrSlice(s string, bounds ...int) string {
+	r := []rune(s)
+
+	start, end := sliceBounds(len(r), bounds)
+	if start >= end {
+		return ""
+	}
+
+	return string(r[start:end])
+}
+
+//
+// Return the lowest index (in runes) of sub in s[start:end], or -1 if not found (s.find(sub, start, end))
+//
+func Find(s, sub string, bounds ...int) int {
+	return strIndex(s, sub, bounds, strings.Index)
+}
+
+//
+// Return the highest index (in runes) of sub in s[start:end], or -1 if not found (s.rfind(sub, start, end))
+//
+func RFind(s, sub string, bounds ...int) int {
+	return strIndex(s, sub, bounds, strings.LastIndex)
+}
+
+//
+// Like Find, but panic with ValueError if sub is not found (s.index(sub, start, end))
+//
+func StrIndex(s, sub string, bounds ...int) int {
+	i := Find(s, sub, bounds...)
+	if i < 0 {
+		panic("ValueError: substring not found")
+	}
+
+	return i
+}
+
+//
+// Pad s on the left with zeros, after the sign, to the specified width (s.zfill(width))
+//
+func ZFill(s string, width int) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	return sign + strings.Repeat("0", n) + s
+}
+
+//
+// Return the fill character for the padding functions (space by default)
+//
+func fillChar(fill []string) string {
+	if len(fill) == 0 {
+		return " "
+	}
+
+	if utf8.RuneCountInString(fill[0]) != 1 {
+		panic("TypeError: The fill character must be exactly one character long")
+	}
+
+	return fill[0]
+}
+
+//
+// Center s in a string of the specified width (s.center(width[, fillchar]))
+//
+func Center(s string, width int, fill ...string) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	c := fillChar(fill)
+	left := n / 2
+	if n%2 == 1 && width%2 == 1 { // same as python, the extra character goes left for odd widths
+		left++
+	}
+
+	return strings.Repeat(c, left) + s + strings.Repeat(c, n-left)
+}
+
+//
+// Pad s on the right to the specified width (s.ljust(width[, fillchar]))
+//
+func LJust(s string, width int, fill ...string) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	return s + strings.Repeat(fillChar(fill), n)
+}
+
+//
+// Pad s on the left to the specified width (s.rjust(width[, fillchar]))
+//
+func RJust(s string, width int, fill ...string) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	return strings.Repeat(fillChar(fill), n) + s
+}
+
+//
+// Split s at line boundaries, including the line terminators if keepends is true (s.splitlines(keepends))
+//
+func SplitLines(s string, keepends bool) []string {
+	lines := []string{}
+
+	for len(s) > 0 {
+		i := strings.IndexAny(s, "\n\r\v\f\x1c\x1d\x1e\u0085\u2028\u2029")
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+
+		_, w := utf8.DecodeRuneInString(s[i:])
+		if strings.HasPrefix(s[i:], "\r\n") {
+			w = 2
+		}
+
+		if keepends {
+			lines = append(lines, s[:i+w])
+		} else {
+			lines = append(lines, s[:i])
+		}
+
+		s = s[i+w:]
+	}
+
+	return lines
+}
+
+//
+// Split s at the first occurrence of sep, returning the part before, the separator and the part after.
+// If sep is not found return s and two empty strings (s.partition(sep))
+//
+func Partition(s, sep string) Tuple {
+	if i := strings.Index(s, sep); i >= 0 {
+		return Tuple{s[:i], sep, s[i+len(sep):]}
+	}
+
+	return Tuple{s, "", ""}
+}
+
+//
+// Split s at the last occurrence of sep, returning the part before, the separator and the part after.
+// If sep is not found return two empty strings and s (s.rpartition(sep))
+//
+func RPartition(s, sep string) Tuple {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return Tuple{s[:i], sep, s[i+len(sep):]}
+	}
+
+	return Tuple{"", "", s}
+}
+
+//
+// Format the number n with thousands separators (format(n, ",")), with prec digits after the decimal point
+// (or the minimum number of digits needed, if prec < 0)
+//
+func FormatThousands(n Any, prec int) string {
+	var s string
+
+	switch v := n.(type) {
+	case int:
+		if prec < 0 {
+			s = strconv.Itoa(v)
+		} else {
+			s = strconv.FormatFloat(float64(v), 'f', prec, 64)
+		}
+
+	case float64:
+		s = strconv.FormatFloat(v, 'f', prec, 64)
+
+	default:
+		s = fmt.Sprint(n)
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	frac := ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, frac = s[:i], s[i:]
+	}
+
+	if strings.Trim(s, "0123456789") != "" { // not a number (i.e. NaN or Inf)
+		return sign + s + frac
+	}
+
+	var res strings.Builder
+
+	res.WriteString(sign)
+
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			res.WriteByte(',')
+		}
+
+		res.WriteRune(c)
+	}
+
+	res.WriteString(frac)
+	return res.String()
+}
+
+// format v with thousands separators, with the width and precision in spec (i.e. "10.2")
+func thousands(v Any, spec, verb string) string {
+	prec := -1
+
+	if i := strings.IndexByte(spec, '.'); i >= 0 {
+		prec, _ = strconv.Atoi(spec[i+1:])
+		spec = spec[:i]
+	} else if verb == "f" || verb == "F" {
+		prec = 6
+	}
+
+	if f, ok := v.(float64); ok && (verb == "d" || verb == "i") {
+		v = int(f)
+	}
+
+	return fmt.Sprintf("%"+spec+"s", FormatThousands(v, prec))
+}
+
+var (
+	reFormatField = regexp.MustCompile(`^(\w*)((?:\.\w+|\[[^\]]+\])*)(?:!([rs]))?(?::(.*))?$`)
+	reFormatPath  = regexp.MustCompile(`\.(\w+)|\[([^\]]+)\]`)
+	reFormatSpec  = regexp.MustCompile(`^([<>])?(\d+)?(,)?(\.\d+)?([sdifFeEgGxXo%])?$`)
+)
+
+//
+// Format the arguments as str.format, for the format strings that can't be converted to fmt.Sprintf.
+// The fields can be positional ({} or {0}) or keywords ({name}), with attribute and index access
+// ({0.name}, {data[0]}, {d[key]}), a conversion ({0!r}) and a format spec ({0:>8.2f}).
+//
+func Format(format string, kwargs Dict, args ...Any) string {
+	var res strings.Builder
+
+	auto := 0 // the next automatic field number
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+
+		switch {
+		case (c == '{' || c == '}') && i+1 < len(format) && format[i+1] == c: // escaped {{ or }}
+			res.WriteByte(c)
+			i++
+
+		case c == '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				panic("ValueError: Single '{' encountered in format string")
+			}
+
+			m := reFormatField.FindStringSubmatch(format[i+1 : i+end])
+			if m == nil {
+				panic("ValueError: invalid format field " + format[i:i+end+1])
+			}
+
+			var v Any
+
+			if m[1] == "" {
+				v = formatArg(args, auto)
+				auto++
+			} else if n, err := strconv.Atoi(m[1]); err == nil {
+				v = formatArg(args, n)
+			} else if kv, ok := kwargs[m[1]]; ok {
+				v = kv
+			} else {
+				panic("KeyError: " + Repr(m[1]))
+			}
+
+			res.WriteString(formatField(formatPath(v, m[2]), m[3], m[4]))
+			i += end
+
+		default:
+			res.WriteByte(c)
+		}
+	}
+
+	return res.String()
+}
+
+// the positional argument n for Format
+func formatArg(args []Any, n int) Any {
+	if n >= len(args) {
+		panic("IndexError: Replacement index " + strconv.Itoa(n) + " out of range for positional args tuple")
+	}
+
+	return args[n]
+}
+
+// the value of a field path (.name or [index]) in a Format field
+func formatPath(v Any, path string) Any {
+	for _, m := range reFormatPath.FindAllStringSubmatch(path, -1) {
+		if m[1] != "" { // .name
+			rv := reflect.Indirect(reflect.ValueOf(v))
+			if rv.Kind() != reflect.Struct {
+				panic(fmt.Sprintf("AttributeError: '%T' object has no attribute '%v'", v, m[1]))
+			}
+
+			f := rv.FieldByName(m[1])
+			if !f.IsValid() {
+				panic(fmt.Sprintf("AttributeError: '%T' object has no attribute '%v'", v, m[1]))
+			}
+
+			v = fieldValue(f)
+			continue
+		}
+
+		switch t := v.(type) { // [index]
+		case List:
+			n, err := strconv.Atoi(m[2])
+			if err != nil || n < 0 || n >= len(t) {
+				panic("IndexError: list index out of range")
+			}
+			v = t[n]
+
+		case Dict:
+			kv, ok := t[m[2]]
+			if !ok {
+				panic("KeyError: " + Repr(m[2]))
+			}
+			v = kv
+
+		default:
+			panic(fmt.Sprintf("TypeError: '%T' object is not subscriptable", v))
+		}
+	}
+
+	return v
+}
+
+// the value of a struct field, also for the unexported fields of the basic types
+func fieldValue(f reflect.Value) Any {
+	if f.CanInterface() {
+		return f.Interface()
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(f.Int())
+
+	case reflect.Float32, reflect.Float64:
+		return f.Float()
+
+	case reflect.String:
+		return f.String()
+
+	case reflect.Bool:
+		return f.Bool()
+	}
+
+	return fmt.Sprint(f)
+}
+
+// format a value with the conversion (r or s) and the format spec of a Format field
+func formatField(v Any, conv, spec string) string {
+	switch conv {
+	case "r":
+		v = Repr(v)
+	case "s":
+		v = Str(v)
+	}
+
+	m := reFormatSpec.FindStringSubmatch(spec)
+	if m == nil {
+		panic("ValueError: Invalid format specifier " + Repr(spec))
+	}
+
+	align, width, comma, prec, verb := m[1], m[2], m[3], m[4], m[5]
+
+	if _, ok := v.(string); (ok && align == "") || align == "<" { // strings are left aligned
+		width = "-" + width
+	}
+
+	if comma != "" {
+		return thousands(v, width+prec, verb)
+	}
+
+	suffix := ""
+
+	switch verb {
+	case "", "s":
+		v, verb = Str(v), "s"
+
+	case "d", "i":
+		if f, ok := v.(float64); ok {
+			v = int(f)
+		}
+		verb = "d"
+
+	case "%":
+		f, _ := toFloat(v)
+		v, verb, suffix = f*100, "f", "%"
+		if prec == "" {
+			prec = ".6"
+		}
+
+	default:
+		if n, ok := v.(int); ok && strings.Contains("fFeEgG", verb) {
+			v = float64(n)
+		}
+	}
+
+	return fmt.Sprintf("%"+width+prec+verb, v) + suffix
+}
+
+var reMapSpec = regexp.MustCompile(`^\(([^)]*)\)([-+ #0,]*\d*(?:\.\d+)?)([sdirfFeEgGxXocv])`)
+
+//
+// Format the named placeholders ("%(name)s") with the values in d (format % d).
+// The "," flag ("%(name),.2f") adds thousands separators, as in "{name:,.2f}".format_map(d),
+// and the "v" verb ("%(name)8v") left aligns the strings and right aligns anything else, as "{name:8}".format_map(d)
+//
+func FormatMap(format string, d Dict) string {
+	var res strings.Builder
+
+	for {
+		i := strings.IndexByte(format, '%')
+		if i < 0 || i == len(format)-1 {
+			res.WriteString(format)
+			break
+		}
+
+		res.WriteString(format[:i])
+		format = format[i+1:]
+
+		if format[0] == '%' {
+			res.WriteByte('%')
+			format = format[1:]
+			continue
+		}
+
+		m := reMapSpec.FindStringSubmatch(format)
+		if m == nil {
+			panic("TypeError: format requires a mapping")
+		}
+
+		v, ok := d[m[1]]
+		if !ok {
+			panic("KeyError: " + m[1])
+		}
+
+		if strings.Contains(m[2], ",") {
+			res.WriteString(thousands(v, strings.Replace(m[2], ",", "", -1), m[3]))
+			format = format[len(m[0]):]
+			continue
+		}
+
+		switch verb := m[3]; verb {
+		case "s":
+			res.WriteString(fmt.Sprintf("%"+m[2]+"s", Str(v)))
+
+		case "v":
+			flags := m[2]
+			if _, ok := v.(string); ok { // strings are left aligned
+				flags = "-" + flags
+			}
+			res.WriteString(fmt.Sprintf("%"+flags+"s", Str(v)))
+
+		case "r":
+			res.WriteString(fmt.Sprintf("%"+m[2]+"s", Repr(v)))
+
+		case "d", "i":
+			if f, ok := v.(float64); ok {
+				v = int(f)
+			}
+			res.WriteString(fmt.Sprintf("%"+m[2]+"d", v))
+
+		default:
+			if n, ok := v.(int); ok && strings.Contains("fFeEgG", verb) {
+				v = float64(n)
+			}
+			res.WriteString(fmt.Sprintf("%"+m[2]+verb, v))
+		}
+
+		format = format[len(m[0]):]
+	}
+
+	return res.String()
+}
+
+//
+// Check if the path exists (os.path.exists(path))
+//
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+//
+// Check if the path is an existing regular file (os.path.isfile(path))
+//
+func IsFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+//
+// Check if the path is an existing directory (os.path.isdir(path))
+//
+func IsDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+//
+// Split the path in root and extension (os.path.splitext(path)).
+// A leading dot in the file name is not considered an extension.
+//
+func SplitExt(path string) Tuple {
+	ext := filepath.Ext(path)
+	if strings.TrimLeft(filepath.Base(path), ".") == strings.TrimLeft(ext, ".") {
+		ext = ""
+	}
+
+	return Tuple{path[:len(path)-len(ext)], ext}
+}
+
+//
+// Parse a JSON document (json.loads(s)).
+// Objects are returned as Dict, arrays as List and numbers as int or float64.
+//
+func JSONLoads(s string) Any {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var v Any
+	if err := dec.Decode(&v); err != nil {
+		panic("JSONDecodeError: " + err.Error())
+	}
+
+	return fromJSON(v)
+}
+
+func fromJSON(v Any) Any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(string(t)); err == nil {
+			return i
+		}
+
+		f, _ := t.Float64()
+		return f
+
+	case Dict:
+		for k, e := range t {
+			t[k] = fromJSON(e)
+		}
+
+	case List:
+		for i, e := range t {
+			t[i] = fromJSON(e)
+		}
+	}
+
+	return v
+}
+
+//
+// Serialize a value to JSON (json.dumps(v[, indent=n])), with the same separators as Python.
+// Dict keys are sorted.
+//
+func JSONDumps(v Any, indent ...int) string {
+	var b strings.Builder
+
+	prefix := ""
+	if len(indent) > 0 {
+		prefix = strings.Repeat(" ", indent[0])
+	}
+
+	toJSON(&b, v, prefix, "\n")
+	return b.String()
+}
+
+func toJSON(b *strings.Builder, v Any, indent, nl string) {
+	// the separator before each element, and before the closing bracket
+	sep, end := "", ""
+	if indent != "" {
+		sep, end = nl+indent, nl
+	}
+
+	switch t := v.(type) {
+	case nil:
+		b.WriteString("null")
+
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+
+	case float64:
+		switch {
+		case math.IsInf(t, 1):
+			b.WriteString("Infinity")
+		case math.IsInf(t, -1):
+			b.WriteString("-Infinity")
+		case math.IsNaN(t):
+			b.WriteString("NaN")
+		default:
+			b.WriteString(Repr(t))
+		}
+
+	case string:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(t)
+		b.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+
+	case List: // or Tuple
+		if len(t) == 0 {
+			b.WriteString("[]")
+			return
+		}
+
+		b.WriteString("[")
+		for i, e := range t {
+			if i > 0 {
+				b.WriteString(",")
+				if indent == "" {
+					b.WriteString(" ")
+				}
+			}
+			b.WriteString(sep)
+			toJSON(b, e, indent, sep)
+		}
+		b.WriteString(end + "]")
+
+	case *DefaultDict:
+		toJSON(b, t.Dict, indent, nl)
+
+	case Dict:
+		if len(t) == 0 {
+			b.WriteString("{}")
+			return
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("{")
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteString(",")
+				if indent == "" {
+					b.WriteString(" ")
+				}
+			}
+			b.WriteString(sep)
+			toJSON(b, k, indent, sep)
+			b.WriteString(": ")
+			toJSON(b, t[k], indent, sep)
+		}
+		b.WriteString(end + "}")
+
+	default: // int and other values
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic("TypeError: " + err.Error())
+		}
+		b.Write(data)
+	}
+}
+
+// The random generator used by the random functions. Python seeds it from the system,
+// call Seed (random.seed(n)) to get a repeatable sequence.
+// Note that, as the generator in the python random module, it's not safe for concurrent use.
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+//
+// Initialize the random generator (random.seed(n))
+//
+func Seed(n int) {
+	rng = rand.New(rand.NewSource(int64(n)))
+}
+
+//
+// Return a random float in [0.0, 1.0) (random.random())
+//
+func Random() float64 {
+	return rng.Float64()
+}
+
+//
+// Return a random float in [a, b) (random.uniform(a, b))
+//
+func Uniform(a, b float64) float64 {
+	return a + (b-a)*rng.Float64()
+}
+
+//
+// Return a random integer in [a, b], including both end points (random.randint(a, b))
+//
+func RandInt(a, b int) int {
+	if b < a {
+		panic(fmt.Sprintf("ValueError: empty range for randint(%v, %v)", a, b))
+	}
+
+	return a + rng.Intn(b-a+1)
+}
+
+//
+// Return a random element from a non-empty sequence (random.choice(seq))
+//
+func Choice(seq Any) Any {
+	l := ToList(seq)
+	if len(l) == 0 {
+		panic("IndexError: Cannot choose from an empty sequence")
+	}
+
+	return l[rng.Intn(len(l))]
+}
+
+//
+// Shuffle the list in place (random.shuffle(l))
+//
+func Shuffle(l List) {
+	rng.Shuffle(len(l), func(i, j int) {
+		l[i], l[j] = l[j], l[i]
+	})
+}
+
+//
+// Check if a and b are the same object (a is b).
+// Pointers, maps, slices, functions and channels are compared by reference, other values by value.
+//
+func Identity(a, b Any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() { // nil
+		return !va.IsValid() && !vb.IsValid()
+	}
+
+	if va.Type() != vb.Type() {
+		return false
+	}
+
+	switch va.Kind() {
+	case reflect.Slice:
+		return va.Pointer() == vb.Pointer() && va.Len() == vb.Len()
+
+	case reflect.Ptr, reflect.Map, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+
+	return va.Type().Comparable() && a == b
+}
+
+//
+// Return a list of tuples, where the i-th tuple contains the i-th element from each of the iterables.
+// The list is as long as the shortest iterable (zip(a, b, ...))
+//
+func Zip(iterables ...Any) List {
+	lists := make([]List, len(iterables))
+	n := -1
+
+	for i, it := range iterables {
+		lists[i] = ToList(it)
+		if n < 0 || len(lists[i]) < n {
+			n = len(lists[i])
+		}
+	}
+
+	res := List{}
+	for i := 0; i < n; i++ {
+		t := make(Tuple, len(lists))
+		for j, l := range lists {
+			t[j] = l[i]
+		}
+
+		res = append(res, t)
+	}
+
+	return res
+}
+
+//
+// Return the numbers from start to stop (excluded) by step (range(start, stop, step)),
+// for a step that is only known at runtime: the direction depends on the sign of step
+//
+func Range(start, stop, step int) []int {
+	if step == 0 {
+		panic("ValueError: range() arg 3 must not be zero")
+	}
+
+	var res []int
+
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		res = append(res, i)
+	}
+
+	return res
+}
+
+//
+// Return the elements of all the iterables, in order, as a single list (itertools.chain(a, b, ...))
+//
+func Chain(iterables ...Any) List {
+	res := List{}
+
+	for _, it := range iterables {
+		res = append(res, ToList(it)...)
+	}
+
+	return res
+}
+
+//
+// Return a channel with the numbers start, start+step, start+2*step... (itertools.count(start=0, step=1)).
+// Note that the counter is infinite: the loop reading from it should break at some point,
+// and the goroutine sending the numbers never terminates.
+//
+func Count(args ...Any) chan Any {
+	var n, step Any = 0, 1
+
+	if len(args) > 0 {
+		n = args[0]
+	}
+	if len(args) > 1 {
+		step = args[1]
+	}
+
+	c := make(chan Any)
+
+	go func() {
+		for {
+			c <- n
+			n = Add(n, step)
+		}
+	}()
+
+	return c
+}
+
+//
+// Return a list with the value repeated n times (itertools.repeat(v, n))
+//
+func RepeatN(v Any, n int) List {
+	res := List{}
+
+	for i := 0; i < n; i++ {
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// the reference time for Monotonic (time.Now includes a monotonic clock reading)
+var startTime = time.Now()
+
+//
+// Return the value of a monotonic clock, in seconds (time.monotonic(), time.perf_counter()).
+// Only the difference between two values is meaningful.
+//
+func Monotonic() float64 {
+	return time.Since(startTime).Seconds()
+}
+
+// logging levels
+const (
+	LogDebug    = 10
+	LogInfo     = 20
+	LogWarning  = 30
+	LogError    = 40
+	LogCritical = 50
+)
+
+var logNames = map[int]string{
+	LogDebug:    "DEBUG",
+	LogInfo:     "INFO",
+	LogWarning:  "WARNING",
+	LogError:    "ERROR",
+	LogCritical: "CRITICAL",
+}
+
+var (
+	// the logger used by Log, with the same output as the default python logging configuration
+	Logger = log.New(os.Stderr, "", 0)
+
+	logLevel = LogWarning
+)
+
+//
+// Set the minimum level of the messages that are logged (logging.basicConfig(level=level))
+//
+func SetLogLevel(level int) {
+	logLevel = level
+}
+
+//
+// Log a message, formatted with the optional arguments, if level is enabled (logging.info(msg, args...))
+//
+func Log(level int, msg string, args ...Any) {
+	if level < logLevel {
+		return
+	}
+
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	name, ok := logNames[level]
+	if !ok {
+		name = fmt.Sprintf("Level %d", level)
+	}
+
+	Logger.Printf("%s:root:%s", name, msg)
+}
@@ -1,9 +1,24 @@
 package runtime
 
+import "bytes"
+import "log"
+import "os"
+import "path/filepath"
+import "reflect"
+import "strings"
 import "testing"
+import "time"
 
 func TestAssert(t *testing.T) {
-	Assert(true, "this should be true")
+	Assert(true, 1, "this should be true")
+
+	defer func() {
+		if r := recover(); r != "AssertionError: line 2" {
+			t.Error("unexpected assertion", r)
+		}
+	}()
+
+	Assert(false, 2, "")
 }
 
 func TestContainsString(t *testing.T) {
@@ -157,9 +172,1009 @@ func TestReverse(t *testing.T) {
 	}
 }
 
+func TestDelIndex(t *testing.T) {
+	if s := Repr(DelIndex(List{1, 2, 3}, 1)); s != "[1, 3]" {
+		t.Error("unexpected del", s)
+	}
+
+	if s := Repr(DelIndex(List{1, 2, 3}, -1)); s != "[1, 2]" {
+		t.Error("unexpected del of negative index", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("del out of range should panic")
+		}
+	}()
+
+	DelIndex(List{1, 2, 3}, 3)
+}
+
 func TestSplits(t *testing.T) {
 	parts := Splits("hello there   a\t more\n\r here")
 	if len(parts) != 5 {
 		t.Error("incorrect split")
 	}
 }
+
+func TestIntPow(t *testing.T) {
+	if IntPow(2, 10) != 1024 {
+		t.Error("2 ** 10 should be 1024")
+	}
+
+	if IntPow(7, 0) != 1 {
+		t.Error("7 ** 0 should be 1")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a negative exponent should panic")
+		}
+	}()
+
+	IntPow(2, -1)
+}
+
+func TestModPow(t *testing.T) {
+	if ModPow(4, 13, 497) != 445 {
+		t.Error("pow(4, 13, 497) should be 445")
+	}
+
+	if ModPow(-2, 3, 5) != 2 {
+		t.Error("pow(-2, 3, 5) should be 2")
+	}
+
+	if ModPow(2, 3, -5) != -2 {
+		t.Error("pow(2, 3, -5) should be -2")
+	}
+
+	if n := ModPow(3, 1000, 2305843009213693951); n != 1236409068333599307 {
+		t.Error("pow(3, 1000, 2**61 - 1) should be 1236409068333599307, got", n)
+	}
+}
+
+func TestSetSlice(t *testing.T) {
+	l := List{1, 2, 3, 4, 5}
+	SetSlice(&l, 1, 3, List{9, 9, 9})
+
+	r := List{1, 9, 9, 9, 4, 5}
+	if len(l) != len(r) {
+		t.Fatal("incorrect length", l)
+	}
+
+	for i, n := range l {
+		if r[i] != n {
+			t.Error("incorrect slice assignment", l)
+		}
+	}
+
+	SetSlice(&l, 0, 2, List{})
+	if len(l) != 4 || l[0] != 9 {
+		t.Error("incorrect slice removal", l)
+	}
+
+	SetSlice(&l, 10, 20, List{7})
+	if len(l) != 5 || l[4] != 7 {
+		t.Error("incorrect slice append", l)
+	}
+}
+
+func TestStr(t *testing.T) {
+	if s := Str(List{1, "two", 3.0, nil, true}); s != "[1, 'two', 3.0, None, True]" {
+		t.Error("incorrect list conversion", s)
+	}
+
+	if s := Str(Dict{"b": 2, "a": List{1}}); s != "{'a': [1], 'b': 2}" {
+		t.Error("incorrect dict conversion", s)
+	}
+
+	if s := Str("hello"); s != "hello" {
+		t.Error("incorrect string conversion", s)
+	}
+}
+
+func TestRepr(t *testing.T) {
+	if s := Repr("hello"); s != "'hello'" {
+		t.Error("incorrect string repr", s)
+	}
+
+	if s := Repr("it's"); s != `"it's"` {
+		t.Error("incorrect quoted string repr", s)
+	}
+
+	if s := Repr(1.5); s != "1.5" {
+		t.Error("incorrect float repr", s)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Error("1 + 2 should be 3")
+	}
+
+	if Add(1, 0.5) != 1.5 {
+		t.Error("1 + 0.5 should be 1.5")
+	}
+
+	if Add("a", "b") != "ab" {
+		t.Error(`"a" + "b" should be "ab"`)
+	}
+
+	if l := Add(List{1}, List{2}).(List); len(l) != 2 {
+		t.Error("incorrect list concatenation", l)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	if Sub(5, 7) != -2 {
+		t.Error("5 - 7 should be -2")
+	}
+
+	if Mul("ab", 2) != "abab" {
+		t.Error(`"ab" * 2 should be "abab"`)
+	}
+
+	if Div(7, 2) != 3.5 {
+		t.Error("7 / 2 should be 3.5")
+	}
+
+	if FloorDiv(-7, 2) != -4 {
+		t.Error("-7 // 2 should be -4")
+	}
+
+	if Mod(-7, 3) != 2 {
+		t.Error("-7 % 3 should be 2")
+	}
+}
+
+func TestGetenv(t *testing.T) {
+	os.Setenv("PYGOR_TEST", "set")
+
+	if Getenv("PYGOR_TEST", "default") != "set" {
+		t.Error("variable should be set")
+	}
+
+	os.Unsetenv("PYGOR_TEST")
+
+	if Getenv("PYGOR_TEST", "default") != "default" {
+		t.Error("variable should not be set")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	d := Dict{"one": 1, "two": 2}
+	Update(d, Dict{"two": 22, "three": 3})
+
+	if len(d) != 3 || d["two"] != 22 || d["three"] != 3 {
+		t.Error("incorrect update", d)
+	}
+}
+
+func TestDictPop(t *testing.T) {
+	d := Dict{"one": 1, "two": 2}
+
+	if DictPop(d, "one") != 1 || len(d) != 1 {
+		t.Error("incorrect pop", d)
+	}
+
+	if DictPop(d, "one", 11) != 11 {
+		t.Error("pop should return the default value")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("pop of missing key should panic")
+		}
+	}()
+
+	DictPop(d, "one")
+}
+
+func TestSetDefault(t *testing.T) {
+	d := Dict{"one": 1}
+
+	if SetDefault(d, "one", 11) != 1 {
+		t.Error("setdefault should return the existing value")
+	}
+
+	if SetDefault(d, "two", 2) != 2 || d["two"] != 2 {
+		t.Error("setdefault should set the missing value")
+	}
+}
+
+func TestTruthy(t *testing.T) {
+	for _, v := range []Any{nil, false, 0, 0.0, "", List{}, Dict{}, []string{}} {
+		if Truthy(v) {
+			t.Errorf("%#v should be false", v)
+		}
+	}
+
+	for _, v := range []Any{true, 1, 0.5, "x", List{1}, Dict{"a": 1}, []string{"a"}} {
+		if !Truthy(v) {
+			t.Errorf("%#v should be true", v)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	add := func(a, b int) int { return a + b }
+	if Apply(add, List{1, 2}) != 3 {
+		t.Error("incorrect result for add")
+	}
+
+	count := func(args ...Any) int { return len(args) }
+	if Apply(count, List{1, 2, 3}) != 3 {
+		t.Error("incorrect result for variadic function")
+	}
+
+	swap := func(a, b Any) (Any, Any) { return b, a }
+	if r := Apply(swap, List{1, nil}).(Tuple); r[0] != nil || r[1] != 1 {
+		t.Error("incorrect result for multiple values", r)
+	}
+}
+
+func TestInsertArgs(t *testing.T) {
+	if s := Repr(InsertArgs(List{1, 2, 3}, 1, "kw")); s != "[1, 'kw', 2, 3]" {
+		t.Error("unexpected insert", s)
+	}
+
+	if s := Repr(InsertArgs(List{1}, 3, "kw")); s != "[1, 'kw']" {
+		t.Error("unexpected insert at the end", s)
+	}
+}
+
+func TestMergeDicts(t *testing.T) {
+	d1 := Dict{"one": 1, "two": 2}
+	d := MergeDicts(d1, Dict{"two": 22, "three": 3})
+
+	if len(d) != 3 || d["one"] != 1 || d["two"] != 22 || d["three"] != 3 {
+		t.Error("incorrect merge", d)
+	}
+
+	if d1["two"] != 2 {
+		t.Error("merge should not modify the arguments", d1)
+	}
+}
+
+type reprOnly struct{}
+
+func (r reprOnly) GoString() string { return "reprOnly()" }
+
+type strAndRepr struct{}
+
+func (r strAndRepr) String() string   { return "str" }
+func (r strAndRepr) GoString() string { return "repr" }
+
+func TestStrRepr(t *testing.T) {
+	if s := Str(reprOnly{}); s != "reprOnly()" {
+		t.Error("str should use __repr__ if there is no __str__", s)
+	}
+
+	if s := Str(strAndRepr{}); s != "str" {
+		t.Error("str should use __str__", s)
+	}
+
+	if s := Repr(strAndRepr{}); s != "repr" {
+		t.Error("repr should use __repr__", s)
+	}
+
+	if s := Str(List{strAndRepr{}}); s != "[repr]" {
+		t.Error("list elements should use __repr__", s)
+	}
+}
+
+func TestDefaultDict(t *testing.T) {
+	d := NewDefaultDict(func() Any { return 0 })
+
+	d.Set("a", Add(d.Get("a"), 1))
+	d.Set("a", Add(d.Get("a"), 1))
+	if v := d.Get("a"); v != 2 {
+		t.Error("expected 2, got", v)
+	}
+
+	if v := d.Get("b"); v != 0 {
+		t.Error("missing key should be initialized by the factory", v)
+	}
+
+	if d.Len() != 2 || !Contains(d, "b") {
+		t.Error("unexpected content", Repr(d))
+	}
+
+	l := NewDefaultDict(func() Any { return List{} })
+	l.Set("x", append(l.Get("x").(List), 1))
+	if s := Repr(l); s != "defaultdict({'x': [1]})" {
+		t.Error("unexpected repr", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("missing key without a factory should panic")
+		}
+	}()
+
+	NewDefaultDict(nil).Get("x")
+}
+
+func TestSorted(t *testing.T) {
+	l := List{3, 1.5, 2}
+	if s := Repr(Sorted(l, nil, false)); s != "[1.5, 2, 3]" {
+		t.Error("unexpected sort", s)
+	}
+
+	if s := Repr(l); s != "[3, 1.5, 2]" {
+		t.Error("sorted should not modify the list", s)
+	}
+
+	words := List{"bb", "a", "CCC", "dd"}
+	if s := Repr(Sorted(words, Len, true)); s != "['CCC', 'bb', 'dd', 'a']" {
+		t.Error("unexpected reverse sort by len (should be stable)", s)
+	}
+
+	if s := Repr(Sorted(words, strings.ToLower, false)); s != "['a', 'bb', 'CCC', 'dd']" {
+		t.Error("unexpected sort by lower", s)
+	}
+
+	byFirst := func(v Any) Any { return v.(Tuple)[0] }
+	if s := Repr(Sorted(List{Tuple{2, "b"}, Tuple{1, "a"}}, byFirst, false)); s != "[[1, 'a'], [2, 'b']]" {
+		t.Error("unexpected sort by key", s)
+	}
+
+	if s := Repr(Sorted(Dict{"b": 1, "a": 2}, nil, true)); s != "['b', 'a']" {
+		t.Error("unexpected sort of dict keys", s)
+	}
+
+	if s := Repr(Sorted("cab", nil, false)); s != "['a', 'b', 'c']" {
+		t.Error("unexpected sort of string", s)
+	}
+}
+
+func TestItems(t *testing.T) {
+	d := Dict{"b": 1, "a": 2}
+	if s := Repr(Items(d)); s != "[['a', 2], ['b', 1]]" {
+		t.Error("unexpected items", s)
+	}
+
+	byValue := func(v Any) Any { return v.(Tuple)[1] }
+	if s := Repr(Sorted(Items(d), byValue, false)); s != "[['b', 1], ['a', 2]]" {
+		t.Error("unexpected items sorted by value", s)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	if s := Repr(ToList("abc")); s != "['a', 'b', 'c']" {
+		t.Error("unexpected list from string", s)
+	}
+
+	if s := Repr(ToList(Dict{"b": 1, "a": 2})); s != "['a', 'b']" {
+		t.Error("unexpected list from dict", s)
+	}
+
+	c := make(chan Any)
+	go func() {
+		defer close(c)
+		c <- 1
+		c <- 2
+	}()
+
+	if s := Repr(ToTuple(c)); s != "[1, 2]" {
+		t.Error("unexpected tuple from generator", s)
+	}
+
+	set := ToSet(List{3, 1, 3, 2})
+	if len(set) != 3 || !Contains(set, 1) || Contains(set, 4) {
+		t.Error("unexpected set", Repr(set))
+	}
+
+	if s := Repr(set); s != "{1, 2, 3}" {
+		t.Error("unexpected set repr", s)
+	}
+
+	if s := Repr(ToSet(nil)); s != "set()" {
+		t.Error("unexpected empty set repr", s)
+	}
+
+	if s := Repr(ToDict(List{Tuple{"a", 1}, List{"b", 2}})); s != "{'a': 1, 'b': 2}" {
+		t.Error("unexpected dict from pairs", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid pairs should panic")
+		}
+	}()
+
+	ToDict(List{"abc"})
+}
+
+func TestReversed(t *testing.T) {
+	l := List{1, 2, 3}
+
+	if s := Repr(Reversed(l)); s != "[3, 2, 1]" {
+		t.Error("unexpected reversed", s)
+	}
+
+	if s := Repr(l); s != "[1, 2, 3]" {
+		t.Error("reversed should not modify the list", s)
+	}
+
+	if s := Repr(Reversed("ab")); s != "['b', 'a']" {
+		t.Error("unexpected reversed string", s)
+	}
+}
+
+func TestFind(t *testing.T) {
+	s := "abcabc"
+
+	if i := Find(s, "b", 2); i != 4 {
+		t.Error("expected 4, got", i)
+	}
+
+	if i := Find(s, "c", 0, 2); i != -1 {
+		t.Error("expected -1, got", i)
+	}
+
+	if i := RFind(s, "a", 0, -1); i != 3 {
+		t.Error("expected 3, got", i)
+	}
+
+	if i := Find(s, "a", 10); i != -1 {
+		t.Error("expected -1, got", i)
+	}
+
+	if i := StrIndex(s, "c", -2); i != 5 {
+		t.Error("expected 5, got", i)
+	}
+
+	// the positions are in runes, as in StrSlice
+	if i := Find("héllo=wörld", "="); i != 5 || StrSlice("héllo=wörld", i+1) != "wörld" {
+		t.Error("expected 5, got", i)
+	}
+
+	if i := RFind("héllo", "l", 0, -1); i != 3 {
+		t.Error("expected 3, got", i)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("index should panic if not found")
+		}
+	}()
+
+	StrIndex(s, "x")
+}
+
+func TestStrSlice(t *testing.T) {
+	for _, test := range []struct {
+		bounds []int
+		expect string
+	}{
+		{nil, "héllo"},
+		{[]int{1}, "éllo"},
+		{[]int{0, -1}, "héll"},
+		{[]int{-3}, "llo"},
+		{[]int{1, 3}, "él"},
+		{[]int{3, 1}, ""},
+		{[]int{-10, 10}, "héllo"},
+	} {
+		if s := StrSlice("héllo", test.bounds...); s != test.expect {
+			t.Errorf("StrSlice(%v): expected %q, got %q", test.bounds, test.expect, s)
+		}
+	}
+}
+
+func TestPadding(t *testing.T) {
+	tests := []struct{ got, expected string }{
+		{ZFill("42", 5), "00042"},
+		{ZFill("-42", 5), "-0042"},
+		{ZFill("12345", 3), "12345"},
+		{Center("ab", 6), "  ab  "},
+		{Center("abc", 6, "*"), "*abc**"},
+		{Center("ab", 5), "  ab "},
+		{Center("abc", 5), " abc "},
+		{Center("a", 4), " a  "},
+		{LJust("ab", 4), "ab  "},
+		{RJust("ab", 4, "-"), "--ab"},
+		{RJust("ab", 1), "ab"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, test.got)
+		}
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	s := "one\r\ntwo\n\nthree\rfour\n"
+
+	if l := SplitLines(s, false); strings.Join(l, "|") != "one|two||three|four" {
+		t.Errorf("unexpected lines %q", l)
+	}
+
+	if l := SplitLines(s, true); strings.Join(l, "|") != "one\r\n|two\n|\n|three\r|four\n" {
+		t.Errorf("unexpected lines with ends %q", l)
+	}
+
+	if l := SplitLines("", false); len(l) != 0 {
+		t.Errorf("expected no lines, got %q", l)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	if p := Repr(Partition("key=value=x", "=")); p != "['key', '=', 'value=x']" {
+		t.Error("unexpected partition", p)
+	}
+
+	if p := Repr(RPartition("key=value=x", "=")); p != "['key=value', '=', 'x']" {
+		t.Error("unexpected rpartition", p)
+	}
+
+	if p := Repr(Partition("key", "=")); p != "['key', '', '']" {
+		t.Error("unexpected partition", p)
+	}
+
+	if p := Repr(RPartition("key", "=")); p != "['', '', 'key']" {
+		t.Error("unexpected rpartition", p)
+	}
+}
+
+func TestFormatMap(t *testing.T) {
+	d := Dict{"name": "bob", "age": 42, "score": 9.5}
+
+	if s := FormatMap("%(name)s is %(age)d (%(score).2f) 100%%", d); s != "bob is 42 (9.50) 100%" {
+		t.Error("unexpected format", s)
+	}
+
+	if s := FormatMap("[%(name)-5s|%(age)5d|%(name)r]", d); s != "[bob  |   42|'bob']" {
+		t.Error("unexpected format with width", s)
+	}
+
+	if s := FormatMap("[%(name)5v|%(age)5v]", d); s != "[bob  |   42]" {
+		t.Error("unexpected default alignment", s)
+	}
+
+	if s := FormatMap("%(age).1f", d); s != "42.0" {
+		t.Error("unexpected float format of an int", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("missing key should panic")
+		}
+	}()
+
+	FormatMap("%(missing)s", d)
+}
+
+func TestFormat(t *testing.T) {
+	type point struct {
+		X, y int
+	}
+
+	p := &point{X: 1, y: 2}
+	data := List{"a", 3.5}
+	d := Dict{"key": "value"}
+
+	kwargs := Dict{"p": p, "data": data, "d": d}
+
+	for _, test := range []struct {
+		format string
+		args   List
+		expect string
+	}{
+		{"{} {}", List{1, List{1, 2}}, "1 [1, 2]"},
+		{"{1[0]} {0}!", List{1, List{1, 2}}, "1 1!"},
+		{"{p.X},{p.y}", nil, "1,2"},
+		{"{data[0]!r} {data[1]:>6.2f}", nil, "'a'   3.50"},
+		{"[{d[key]:8}] {{x}}", nil, "[value   ] {x}"},
+		{"{0:,} {1:.1%}", List{1234, 0.25}, "1,234 25.0%"},
+	} {
+		if s := Format(test.format, kwargs, test.args...); s != test.expect {
+			t.Errorf("unexpected format %q: %q", test.format, s)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("missing argument should panic")
+		}
+	}()
+
+	Format("{2}", nil, 1)
+}
+
+func TestFormatThousands(t *testing.T) {
+	for _, test := range []struct {
+		n      Any
+		prec   int
+		expect string
+	}{
+		{0, -1, "0"},
+		{999, -1, "999"},
+		{1000, -1, "1,000"},
+		{-1234567, -1, "-1,234,567"},
+		{1234567, 2, "1,234,567.00"},
+		{1234.5, -1, "1,234.5"},
+		{-9876543.219, 2, "-9,876,543.22"},
+	} {
+		if s := FormatThousands(test.n, test.prec); s != test.expect {
+			t.Errorf("FormatThousands(%v, %v): expected %q, got %q", test.n, test.prec, test.expect, s)
+		}
+	}
+
+	d := Dict{"n": 1234567, "f": 1234.5}
+	if s := FormatMap("[%(n),d|%(f),.2f|%(n),12d]", d); s != "[1,234,567|1,234.50|   1,234,567]" {
+		t.Error("unexpected format with thousands separators", s)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	dir := os.TempDir()
+
+	if !Exists(dir) || !IsDir(dir) || IsFile(dir) {
+		t.Error("expected existing directory", dir)
+	}
+
+	if Exists(filepath.Join(dir, "does-not-exist.pygor")) {
+		t.Error("unexpected existing file")
+	}
+
+	tests := map[string]string{
+		"a/b.txt":    "['a/b', '.txt']",
+		"a.tar.gz":   "['a.tar', '.gz']",
+		".bashrc":    "['.bashrc', '']",
+		"a/.b.txt":   "['a/.b', '.txt']",
+		"noext":      "['noext', '']",
+		"dir.d/file": "['dir.d/file', '']",
+	}
+
+	for p, expected := range tests {
+		if s := Repr(SplitExt(p)); s != expected {
+			t.Errorf("splitext(%q): expected %v, got %v", p, expected, s)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	v := JSONLoads(`{"a": 1, "b": [1.5, "x", null, true], "c": {}}`)
+
+	d, ok := v.(Dict)
+	if !ok {
+		t.Fatalf("expected Dict, got %T", v)
+	}
+
+	if d["a"] != 1 {
+		t.Errorf("expected int 1, got %T %v", d["a"], d["a"])
+	}
+
+	if s := JSONDumps(v); s != `{"a": 1, "b": [1.5, "x", null, true], "c": {}}` {
+		t.Error("unexpected dumps", s)
+	}
+
+	if s := JSONDumps(Dict{"k": List{1, 2}, "e": List{}}, 2); s != "{\n  \"e\": [],\n  \"k\": [\n    1,\n    2\n  ]\n}" {
+		t.Error("unexpected dumps with indent", s)
+	}
+
+	if s := JSONDumps("<a&b>"); s != `"<a&b>"` {
+		t.Error("unexpected string dumps", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid JSON should panic")
+		}
+	}()
+
+	JSONLoads("{invalid")
+}
+
+func TestRandom(t *testing.T) {
+	Seed(42)
+	first := List{RandInt(1, 100), Random()}
+
+	Seed(42)
+	if s := Repr(List{RandInt(1, 100), Random()}); s != Repr(first) {
+		t.Error("the same seed should generate the same sequence", s, Repr(first))
+	}
+
+	for i := 0; i < 100; i++ {
+		if n := RandInt(1, 3); n < 1 || n > 3 {
+			t.Fatal("randint out of range", n)
+		}
+
+		if f := Uniform(2, 3); f < 2 || f >= 3 {
+			t.Fatal("uniform out of range", f)
+		}
+
+		if c := Choice("abc"); !Contains("abc", c) {
+			t.Fatal("unexpected choice", c)
+		}
+	}
+
+	l := List{1, 2, 3, 4, 5}
+	Shuffle(l)
+	if s := Repr(Sorted(l, nil, false)); s != "[1, 2, 3, 4, 5]" {
+		t.Error("shuffle should preserve the elements", s)
+	}
+}
+
+type testContext struct {
+	entered, exited bool
+}
+
+func (c *testContext) Enter() Any {
+	c.entered = true
+	return "value"
+}
+
+func (c *testContext) Exit(excType, exc, tb Any) Any {
+	c.exited = true
+	return nil
+}
+
+func TestContextManager(t *testing.T) {
+	ctx := &testContext{}
+
+	if v := Enter(ctx); v != "value" || !ctx.entered {
+		t.Errorf("expected value from Enter, got %v", v)
+	}
+
+	Exit(ctx)
+	if !ctx.exited {
+		t.Error("Exit was not called")
+	}
+
+	if v := Enter(42); v != 42 {
+		t.Errorf("expected the context itself, got %v", v)
+	}
+
+	Exit(42) // not a context manager, nothing to do
+}
+
+func TestAssertEqual(t *testing.T) {
+	if !Equal(1, 1.0) || !Equal(List{1, "a"}, List{1, "a"}) || Equal(Dict{"a": 1}, Dict{"a": 2}) {
+		t.Error("unexpected Equal result")
+	}
+
+	AssertEqual(Dict{"a": List{1}}, Dict{"a": List{1}}, 1, "equal")
+
+	defer func() {
+		if r := recover(); r != "AssertionError: line 1: 1 != 2" {
+			t.Error("unexpected assertion", r)
+		}
+	}()
+
+	AssertEqual(1, 2, 1, "")
+}
+
+func TestAssertCmp(t *testing.T) {
+	AssertCmp(1, "<", 2.5, 1, "less")
+	AssertCmp(2, ">=", 2, 2, "greater or equal")
+	AssertCmp("a", "in", List{"a", "b"}, 3, "in")
+	AssertCmp(List{1}, "!=", List{2}, 4, "not equal")
+
+	defer func() {
+		if r := recover(); r != "AssertionError: line 3: too big: assert 5 <= 4" {
+			t.Error("unexpected assertion", r)
+		}
+	}()
+
+	AssertCmp(5, "<=", 4, 3, "too big")
+}
+
+func TestAssertRaises(t *testing.T) {
+	type ValueError struct{}
+
+	AssertRaises(func() { panic(RaisedException(ValueError{})) }, "ValueError")
+
+	defer func() {
+		if r := recover(); r != "AssertionError: KeyError not raised" {
+			t.Error("unexpected assertion", r)
+		}
+	}()
+
+	AssertRaises(func() {}, "KeyError")
+}
+
+func TestSuppress(t *testing.T) {
+	suppressed := func(exceptions ...string) (ok bool) {
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+
+		func() {
+			defer Suppress(exceptions...)
+
+			_, err := os.Open(filepath.Join(os.TempDir(), "pygor-does-not-exist"))
+			panic(err)
+		}()
+
+		return true
+	}
+
+	if !suppressed("FileNotFoundError") {
+		t.Error("FileNotFoundError should be suppressed")
+	}
+
+	if !suppressed("KeyError", "Exception") {
+		t.Error("Exception should suppress everything")
+	}
+
+	if suppressed("PermissionError") {
+		t.Error("PermissionError should not suppress a missing file")
+	}
+
+	type ValueError struct{}
+
+	if !IsException(RaisedException(ValueError{}), "ValueError") {
+		t.Error("raised exceptions should match by type name")
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	a := List{1, 2}
+	b := List{1, 2}
+	c := a
+
+	if !Identity(a, c) || Identity(a, b) {
+		t.Error("lists should be compared by reference")
+	}
+
+	if Identity(a, a[:1]) {
+		t.Error("a slice of a list is a different object")
+	}
+
+	d1, d2 := Dict{}, Dict{}
+	if !Identity(d1, d1) || Identity(d1, d2) {
+		t.Error("dicts should be compared by reference")
+	}
+
+	if !Identity(nil, nil) || Identity(nil, a) {
+		t.Error("unexpected nil identity")
+	}
+
+	if !Identity(1, 1) || Identity(1, 1.0) {
+		t.Error("unexpected value identity")
+	}
+}
+
+func TestZip(t *testing.T) {
+	if s := Repr(Zip(List{1, 2, 3}, "ab")); s != "[[1, 'a'], [2, 'b']]" {
+		t.Error("unexpected zip", s)
+	}
+
+	if s := Repr(Zip()); s != "[]" {
+		t.Error("unexpected empty zip", s)
+	}
+}
+
+func TestPyExceptionString(t *testing.T) {
+	if s := Str(RaisedException("invalid value")); s != "invalid value" {
+		t.Error("unexpected exception string", s)
+	}
+
+	if s := Str(RaisedException(42)); s != "42" {
+		t.Error("unexpected exception print", s)
+	}
+}
+
+func TestNext(t *testing.T) {
+	gen := make(chan Any)
+	go func() {
+		gen <- 1
+		close(gen)
+	}()
+
+	if v := Next(gen); v != 1 {
+		t.Error("unexpected next", v)
+	}
+
+	if v := Next(gen, "done"); v != "done" {
+		t.Error("unexpected default", v)
+	}
+
+	defer func() {
+		if r := recover(); !IsException(r, "StopIteration") {
+			t.Error("expected StopIteration", r)
+		}
+	}()
+
+	Next(gen)
+}
+
+func TestMakeTuple(t *testing.T) {
+	pair := func() (Any, Any) { return 1, "a" }
+
+	if s := Repr(MakeTuple(pair())); s != "[1, 'a']" {
+		t.Error("unexpected tuple", s)
+	}
+
+	if s := Repr(MakeTuple()); s != "[]" {
+		t.Error("unexpected empty tuple", s)
+	}
+}
+
+func TestRange(t *testing.T) {
+	if r := Range(0, 10, 3); !reflect.DeepEqual(r, []int{0, 3, 6, 9}) {
+		t.Error("unexpected range", r)
+	}
+
+	if r := Range(5, 0, -2); !reflect.DeepEqual(r, []int{5, 3, 1}) {
+		t.Error("unexpected descending range", r)
+	}
+
+	if r := Range(0, 5, -1); len(r) != 0 {
+		t.Error("unexpected empty range", r)
+	}
+}
+
+func TestChain(t *testing.T) {
+	if s := Repr(Chain(List{1, 2}, Tuple{3}, "ab")); s != "[1, 2, 3, 'a', 'b']" {
+		t.Error("unexpected chain", s)
+	}
+
+	if s := Repr(Chain()); s != "[]" {
+		t.Error("unexpected empty chain", s)
+	}
+}
+
+func TestCount(t *testing.T) {
+	var res List
+
+	for n := range Count(10, 5) {
+		if len(res) == 3 {
+			break
+		}
+
+		res = append(res, n)
+	}
+
+	if s := Repr(res); s != "[10, 15, 20]" {
+		t.Error("unexpected count", s)
+	}
+
+	if n := <-Count(); n != 0 {
+		t.Error("unexpected count start", n)
+	}
+}
+
+func TestRepeatN(t *testing.T) {
+	if s := Repr(RepeatN("x", 3)); s != "['x', 'x', 'x']" {
+		t.Error("unexpected repeat", s)
+	}
+
+	if s := Repr(RepeatN(1, 0)); s != "[]" {
+		t.Error("unexpected empty repeat", s)
+	}
+}
+
+func TestMonotonic(t *testing.T) {
+	start := Monotonic()
+	time.Sleep(10 * time.Millisecond)
+
+	if elapsed := Monotonic() - start; elapsed < 0.01 || elapsed > 1 {
+		t.Error("unexpected elapsed time", elapsed)
+	}
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	defer func(l *log.Logger, level int) {
+		Logger, logLevel = l, level
+	}(Logger, logLevel)
+
+	Logger = log.New(&buf, "", 0)
+
+	Log(LogInfo, "not logged by default")
+	Log(LogWarning, "value %d", 42)
+
+	SetLogLevel(LogDebug)
+	Log(LogDebug, "debug")
+
+	if s := buf.String(); s != "WARNING:root:value 42\nDEBUG:root:debug\n" {
+		t.Errorf("unexpected log %q", s)
+	}
+}
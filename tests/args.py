@@ -0,0 +1,52 @@
+# test variable arguments
+
+def total(*values):
+    t = 0
+    for v in values:
+        t += v
+    return t
+
+def add(a, b):
+    return a + b
+
+xs = [1, 2, 3]
+print(total(*xs))
+
+pair = [1, 2]
+print(add(*pair))
+
+def options(name, **kwargs):
+    for k, v in kwargs.items():
+        print(name, k, v)
+
+opts = {"color": "red", "size": 10}
+options("shirt", **opts)
+options("shirt", size=12, **opts)
+options(name="hat", color="blue")
+
+def search(items, *, key=None, reverse=False):
+    return sorted(items, key=key, reverse=reverse)
+
+def connect(host, *hosts, port=80, timeout):
+    print(host, hosts, port, timeout)
+
+# the keywords go in the position of their parameter, with the defaults for the others:
+# search(List{3, 1, 2}, nil, true), connect("a", 80, 10, "b", "c")
+print(search([3, 1, 2], reverse=True))
+connect("a", "b", "c", timeout=10)
+
+# defaults referencing other names are initialized at the top of the body
+LIMIT = 10
+
+def window(start, end=start, size=LIMIT, *, step=size // 2):
+    print(start, end, size, step)
+
+window(1, None, None, step=None)
+
+# the **kw map comes before the *args values: f(1, nil, 2, 3)
+def log_all(level, *args, **kw):
+    print(level, args, kw)
+
+log_all(1, 2, 3)
+log_all(1, 2, 3, sep="-")
+log_all(*[1, 2], sep="-")
@@ -0,0 +1,12 @@
+# test command line arguments
+
+import sys
+
+print(len(sys.argv))
+print(sys.argv[0])
+print(sys.argv[1])
+print(sys.argv[-1])
+
+args = sys.argv[1:]
+for a in sys.argv[1:]:
+    print(a)
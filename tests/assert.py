@@ -0,0 +1,26 @@
+# test assert (with -no-assert the assertions are removed, as python -O)
+
+def check(lo, x, hi):
+    assert lo <= x <= hi, "out of range"
+    assert x
+    return x
+
+def positive(x):
+    assert x > 0, "bad value %d" % x
+    assert x < 100, x
+    return x
+
+class Point:
+    pass
+
+def typed(x, p):
+    assert isinstance(x, int)
+    assert isinstance(x, (int, float)), "not a number"
+    assert isinstance(p, Point)
+    return isinstance(x, str)
+
+def compare(a, b, items):
+    assert a == b, "not equal"
+    assert a < b
+    assert a in items, "missing"
+    assert 0 <= a <= b  # chained: a plain assert
@@ -5,3 +5,22 @@ a,b,c = 1,2,3
 a = [1, 2, 3, 4, 5]
 b = (1, 2, 3, 4, 5)
 c = ((1, 2), (3, 4), (5, 6))
+
+counts = {"a": 0, "b": 0}
+for k in ["a", "b", "a"]:
+    counts[k] += 1
+
+cache = {}
+x = cache["k"] = len(a)
+print(x, cache)
+
+# an augmented assignment as the first use declares the variable
+visits += 1
+print(visits)
+
+# tuple targets mixing names and subscripts
+b = [1, 2, 3]
+i = 1
+a, b[i] = b[i], 10
+a, b[i] = b[i], a
+print(a, b)
@@ -0,0 +1,42 @@
+# string accumulators in loops (use -builder to convert to strings.Builder)
+
+def join_lines(lines):
+    result = ""
+    for line in lines:
+        result += line
+        result += "\n"
+    return result
+
+
+def repeat(s, n):
+    out = ""
+    i = 0
+    while i < n:
+        out += s
+        i += 1
+    return out
+
+
+def not_an_accumulator(words):
+    text = ""
+    for w in words:
+        text += w
+        print(len(text))  # text is used in the loop: plain concatenation
+    return text
+
+
+print(join_lines(["a", "b", "c"]))
+print(repeat("ab", 3))
+print(not_an_accumulator(["x", "y"]))
+
+
+def two_loops(words):
+    text = ""
+    for w in words:
+        text += w
+    for w in reversed(words):  # textBuilder2
+        text += w
+    return text
+
+
+print(two_loops(["x", "y"]))
@@ -0,0 +1,17 @@
+# test bytes
+
+header = b"\x89PNG"
+print(header[0] == 0x89)
+
+buf = bytearray(b"hello")
+buf[0] = 72
+print(buf)
+
+data = bytes(buf)
+first = data[1]
+print(first + 1)
+
+zeros = bytearray(8)
+raw = bytes("text", "utf-8")
+empty = bytearray()
+print(len(zeros), raw, empty)
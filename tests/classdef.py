@@ -19,3 +19,75 @@ class test(object):
     #class nested(object):
     #    pass
         
+
+class point(object):
+    x = 0
+    y = 0
+
+t = test(5)
+t.printn(2)
+
+p = point(x=1, y=2)
+
+class counter(object):
+    count = 0
+    total = None
+
+    def add(self, v):
+        self.count += 1
+        self.total += v
+
+class money(object):
+    def __init__(self, amount):
+        self.amount = amount
+
+    def __str__(self):
+        return "$%s" % self.amount
+
+    def __repr__(self):
+        return "money(%r)" % self.amount
+
+m = money(10)
+print(str(m), repr(m))
+
+class Marker: pass
+
+class Documented:
+    """a class with only a docstring"""
+
+class DocumentedPass:
+    """a class with a docstring and pass"""
+    pass
+
+class Tree:
+    class Node:
+        def __init__(self, value):
+            self.value = value
+            self.children = []
+
+    def __init__(self, value):
+        self.root = Tree.Node(value)
+
+    def add(self, value):
+        node = self.Node(value)
+        self.root.children.append(node)
+        return node
+
+t = Tree(1)
+n = t.add(2)
+print(n.value)
+
+class Counter:
+    def __init__(self, name):
+        self.name = name
+        self.count = 0
+        self.history = []
+
+    def add(self, n):
+        self.count += n
+        self.history.append(n)
+        self.last = n
+        return self.total()
+
+    def total(self):
+        return self.count
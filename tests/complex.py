@@ -0,0 +1,13 @@
+# complex numbers
+
+z = 3 + 4j
+w = 1 - 2j
+
+print(z.real, z.imag)
+print(abs(z))
+print(z / w, z * w)
+print(z.conjugate())
+print(z ** 2)
+
+x = -2.5
+print(abs(x))
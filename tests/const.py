@@ -0,0 +1,20 @@
+# test constants
+
+MAX_SIZE = 100
+MIN_SIZE = 1
+NAME = "pygor"
+
+DEBUG = False
+
+PATH = NAME + ".py"
+limit = 10
+
+# reassigned (or declared global): variables, not constants
+RETRIES = 3
+RETRIES = 5
+
+VERBOSE = False
+
+def set_verbose():
+    global VERBOSE
+    VERBOSE = True
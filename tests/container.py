@@ -0,0 +1,29 @@
+class stack(object):
+    def __init__(self):
+        self.items = []
+
+    def push(self, v):
+        self.items.append(v)
+
+    def __len__(self):
+        return len(self.items)
+
+s = stack()
+s.push(1)
+s.push(2)
+print(len(s))
+
+class sparse(object):
+    def __init__(self):
+        self.cells = {}
+
+    def __getitem__(self, k):
+        return self.cells.get(k, 0)
+
+    def __setitem__(self, k, v):
+        self.cells[k] = v
+
+m = sparse()
+m[(1, 2)] = 5
+m[(1, 2)] += 1
+print(m[(1, 2)], m[(3, 4)])
@@ -0,0 +1,13 @@
+def gen():
+    yield 1
+    yield 2
+
+a = list("abc")
+b = tuple(a)
+c = set([1, 2, 2, 3])
+d = dict([("a", 1), ("b", 2)])
+e = dict(x=1, y=2)
+f = dict(d, z=3)
+g = list(gen())
+empty = list()
+print(a, b, c, d, e, f, g, empty)
@@ -0,0 +1,22 @@
+# test dataclasses
+
+from dataclasses import dataclass
+
+@dataclass
+class Point:
+    x = 0
+    y = 0
+
+    def dist(self):
+        return (self.x ** 2 + self.y ** 2) ** 0.5
+
+p = Point(1, 2)
+print(p, p.dist())
+print(p == Point(1, 2))
+
+@dataclass
+class Path:
+    name = ""
+    points = ()  # compared with runtime.Equal
+
+print(Path() == Path())
@@ -0,0 +1,15 @@
+from collections import defaultdict
+
+counts = defaultdict(int)
+for w in "the quick the lazy the end".split():
+    counts[w] += 1
+
+groups = defaultdict(list)
+for w in ["apple", "avocado", "banana"]:
+    groups[w[0]].append(w)
+
+names = defaultdict(lambda: "unknown")
+print(counts["the"], len(groups), names["x"])
+
+for k, v in groups.items():
+    print(k, v)
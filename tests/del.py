@@ -0,0 +1,24 @@
+# test del
+
+d = {"a": 1, "b": 2}
+del d["a"]
+
+l = [1, 2, 3, 4]
+del l[1]
+del l[-1]
+print(d, l)
+
+class Cache:
+    def __init__(self):
+        self.data = {}
+        self.count = 0
+
+    def reset(self):
+        del self.data
+
+c = Cache()
+c.reset()
+
+big = [0] * 100
+total = 10
+del big, total
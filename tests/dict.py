@@ -0,0 +1,17 @@
+# test dict methods
+
+d = {"a": 1, "b": 2}
+d.update({"c": 3})
+
+a = d.pop("a")
+x = d.pop("x", 0)
+
+l = d.setdefault("l", [])
+
+# not a dict: the methods are called as they are
+l = [1, 2, 3]
+i = 1
+v = l.pop(i)
+
+s = set()
+s.update([1, 2])
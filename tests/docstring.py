@@ -0,0 +1,11 @@
+"""
+Utilities to greet people.
+
+The module docstring becomes the package documentation.
+"""
+
+def greet(name):
+    """return a greeting for name"""
+    return "hello " + name
+
+print(greet("world"))
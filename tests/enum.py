@@ -0,0 +1,37 @@
+# test enum
+
+from enum import Enum, IntEnum, auto
+
+class Color(Enum):
+    """the primary colors"""
+    RED = 1
+    GREEN = 2
+    BLUE = 3
+
+class Priority(IntEnum):
+    LOW = auto()
+    MEDIUM = auto()
+    HIGH = auto()
+
+class Mode(Enum):
+    READ = "r"
+    WRITE = "w"
+
+c = Color.RED
+print(c, Color.GREEN.name, Color.BLUE.value)
+print(Color(2))
+print(Priority.HIGH, Mode.WRITE)
+
+# the members are prefixed with the enum name: LightRED and ColorRED don't clash
+class Light(Enum):
+    RED = 1
+    OFF = 0
+
+print(Light.RED, Light.OFF.value)
+
+# an alias (same value) is printed with the name of the first member: Status.ACTIVE
+class Status(Enum):
+    ACTIVE = 1
+    RUNNING = 1
+
+print(Status.RUNNING)
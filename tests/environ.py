@@ -0,0 +1,7 @@
+# test environment variables
+
+import os
+
+print(os.environ["PATH"])
+print(os.environ.get("HOME"))
+print(os.environ.get("EDITOR", "vi"))
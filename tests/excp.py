@@ -8,3 +8,11 @@ else:
 finally:
     print(x)
 
+
+# the exception value is bound to the name
+try:
+    raise ValueError("invalid value")
+except ValueError as e:
+    print("error:", str(e))
+except KeyError as e:
+    pass
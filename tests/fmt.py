@@ -4,3 +4,45 @@ print("format %s %d" % ("hello", 42))
 
 formatted = "%d-%d-%d" % (1,2,3)
 print(formatted)
+
+mylist = [1, 2, 3]
+print("list: %s" % str(mylist))
+print("repr: " + repr(mylist))
+
+person = {"name": "bob", "age": 42}
+print("%(name)s is %(age)d years old" % person)
+print("%(name)s" % {"name": "alice"})
+print("{name} is {age:>4d} years old ({{literal}})".format_map(person))
+
+# thousands separators
+total = 1234567.891
+print(format(total, ","))
+print(format(total, ",.2f"))
+print(format(total, ">15,.2f"))
+print(format(42, "5d"))
+print("{total:,.2f} ({count:,})".format_map({"total": total, "count": 12345}))
+
+# a single non-tuple value is a single argument
+items = [1, 2, 3]
+pair = ("a", "b")
+print("items: %s" % items)
+print("one: %s" % (items,))
+print("pair: %s-%s" % pair)
+print("repr: %r, %5.1f%%" % ("x", 99.5))
+
+# str.format with attribute and index access
+class User:
+    def __init__(self, name, age):
+        self.name = name
+        self.age = age
+
+user = User("bob", 42)
+print("{0.name} is {0.age:3d}".format(user))
+print("{} and {:>8.2f} {!r}".format("x", 3.14159, "y"))
+print("{data[0]}-{data[1]} {d[key]}".format(data=items, d={"key": "value"}))
+print("{0[0]} {1}".format(pair, 1))
+template = "{user.name} {n:,}"
+print(template.format(user=user, n=1234))  # not a literal: runtime.Format
+
+# strings are left aligned, numbers right aligned: "%(name)8v|%(n)8v|"
+print("{name:8}|{n:8}|".format_map({"name": "bob", "n": 42}))
@@ -30,3 +30,9 @@ while True:
 
 while x < 10:
     x += 1
+
+for k, (v1, v2) in {"a": (1, 2), "b": (3, 4)}.items():
+    print(k, v1, v2)
+
+for (a, b), c in [((1, 2), 3), ((4, 5), 6)]:
+    print(a, b, c)
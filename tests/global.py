@@ -0,0 +1,14 @@
+# test global declarations
+
+counter = 0
+
+def configure(path):
+    global config, counter
+    config = {"path": path}
+    counter += 1
+
+def get_config():
+    return config
+
+configure("/tmp")
+print(get_config(), counter)
@@ -46,3 +46,21 @@ else:
 
 if True:
     print("yes")
+
+n = 5
+parity = "even" if n % 2 == 0 else "odd"
+print(parity)
+print("big" if n > 3 else "small")
+
+# a long elif chain is a flat else if chain
+grade = 75
+if grade >= 90:
+    print("A")
+elif grade >= 80:
+    print("B")
+elif grade >= 70:
+    print("C")
+elif grade >= 60:
+    print("D")
+else:
+    print("F")
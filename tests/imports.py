@@ -0,0 +1,12 @@
+from __future__ import print_function, division
+
+import sys
+from os import path, getcwd
+from os.path import basename as base
+from datetime import datetime
+
+print(getcwd())
+print(path.join("a", "b"))
+print(base("/tmp/file.txt"))
+print(datetime.now())  # not a known symbol: datetime.datetime.now()
+sys.exit(0)
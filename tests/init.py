@@ -0,0 +1,14 @@
+# top level statements are moved to main() (or to init(), with a __main__ guard)
+import sys
+
+if sys.platform == "win32":
+    SEP = "\\"
+    NEWLINE = "\r\n"
+else:
+    SEP = "/"
+    NEWLINE = "\n"
+
+def path(*parts):
+    return SEP.join(parts)
+
+print(path("a", "b"))
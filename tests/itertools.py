@@ -0,0 +1,18 @@
+import itertools
+from itertools import chain
+
+a = [1, 2]
+b = (3, 4)
+
+for x in itertools.chain(a, b, [5]):
+    print(x)
+
+print([x * 2 for x in chain(a, b)])
+
+# count is infinite: the loop needs a break
+for n in itertools.count(10, 5):
+    if n > 30:
+        break
+    print(n)
+
+print(list(itertools.repeat("x", 3)))
@@ -0,0 +1,6 @@
+import json
+
+data = json.loads('{"name": "bob", "tags": ["a", "b"]}')
+print(data["name"])
+print(json.dumps(data))
+print(json.dumps({"x": 1, "y": [1, 2]}, indent=2, sort_keys=True))
@@ -0,0 +1,9 @@
+# test lambdas
+
+double = lambda x: x * 2
+
+r = 3
+key = lambda x, r=1: x * r
+scale = lambda x, r=r: x * r
+
+print(double(2), key(2), scale(2))
@@ -5,3 +5,27 @@ print([x.upper() for x in ["one", "two", "three", "four", "five", "six"]])
 print([x.upper() for x in ["one", "two", "three", "four", "five", "six"] if len(x) <= 4])
 
 print([x for x in range(10)])
+
+xs = [10, 20, 30]
+ys = [1, 2, 3]
+
+indexed = [i for i, x in enumerate(xs)]
+numbered = ["%d: %d" % (i, x) for i, x in enumerate(xs, 1)]
+sums = [a + b for a, b in zip(xs, ys)]
+pairs = [p for p in zip(xs, ys)]
+print(indexed, numbered, sums, pairs)
+
+# multiple generators: the second loop is nested in the first one
+# expected: [10, 20, 30, 20, 40, 60, 30, 60, 90]
+print([x * y for x in ys for y in xs])
+
+# expected: {'1a': 1, '1b': 1, '2a': 2, '2b': 2}
+print({str(n) + c: n for n in [1, 2] for c in ["a", "b"]})
+
+# the condition on the outer generator is checked before the inner loop
+# expected: [[1, 'a'], [1, 'b'], [3, 'a'], [3, 'b']]
+print([(x, y) for x in [1, 2, 3] if x % 2 == 1 for y in ["a", "b"]])
+
+# conditions on both generators
+# expected: [[3, 'b']]
+print([(x, y) for x in [1, 2, 3] if x > 2 for y in ["a", "b"] if y != "a"])
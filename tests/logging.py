@@ -0,0 +1,8 @@
+import logging
+
+logging.basicConfig(level=logging.DEBUG)
+
+logging.debug("starting")
+logging.info("processing %d items", 3)
+logging.warning("disk at %s%%" % 90)
+logging.error("failed")
@@ -0,0 +1,7 @@
+import math
+from math import sqrt
+
+r = 2.0
+print(math.pi * r ** 2, sqrt(16), math.floor(2.5), math.ceil(2.5))
+print(math.sin(0), math.log(math.e), math.hypot(3, 4))
+print(-math.inf < 0)
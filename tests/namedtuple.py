@@ -0,0 +1,11 @@
+# test named tuples
+
+from typing import NamedTuple
+from collections import namedtuple
+
+Point = NamedTuple("Point", [("x", int), ("y", int)])
+Color = namedtuple("Color", "red green blue", defaults=[0])
+
+p = Point(1, 2)
+c = Color(red=255, green=128)
+print(p.x, p.y, c.red)
@@ -0,0 +1,19 @@
+class vector(object):
+    def __init__(self, x, y):
+        self.x = x
+        self.y = y
+
+    def __add__(self, other):
+        return vector(self.x + other.x, self.y + other.y)
+
+    def __eq__(self, other):
+        return self.x == other.x and self.y == other.y
+
+    def __str__(self):
+        return "(%s, %s)" % (self.x, self.y)
+
+a = vector(1, 2)
+b = vector(3, 4)
+c = a + b
+print(c)
+print(a == b, a != b)
@@ -13,6 +13,7 @@ e = 10 // 2
 f = 10 % 2
 
 g = 10 ** 2
+gf = 10 ** -2  # math.Pow
 
 h = 10 << 2
 
@@ -26,3 +27,15 @@ l = 10 ^ 2
 
 m = ~10
 
+
+n = pow(10, 2)
+
+o = pow(4, 13, 497)
+big = pow(3, 1000, 2 ** 61 - 1)
+
+a = [1, 2]
+b = [1, 2]
+c = a
+n = None
+print(a is c, a is not b, a == b)
+print(n is None, a is not None)
@@ -0,0 +1,8 @@
+import os
+
+p = os.path.join("tmp", "data", "file.txt")
+print(os.path.basename(p), os.path.dirname(p))
+print(os.path.splitext(p))
+
+if os.path.exists(p) and os.path.isfile(p):
+    print("found", p)
@@ -0,0 +1,14 @@
+# test print
+
+print("hello", "world")
+print("hello", "world", end="\n")
+print("hello", "world", end="\t")
+print("a", "b", "c", sep=", ", end="")
+print("100", end="%\n")
+
+import sys
+
+print("out", file=sys.stdout)
+print("err", file=sys.stderr)
+print("a", "b", sep="-", file=sys.stderr)
+print("now", flush=True)
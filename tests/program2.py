@@ -0,0 +1,16 @@
+import sys
+
+def run(args):
+    print("I am main", args)
+
+def setup():
+    print("I am a module")
+
+if __name__ == "__main__":
+    args = sys.argv[1:]
+    run(args)
+else:
+    setup()
+
+if __name__ == "__main__":
+    print("done")
@@ -0,0 +1,39 @@
+# test properties
+
+import functools
+
+class circle(object):
+    def __init__(self, radius):
+        self.radius = radius
+
+    @property
+    def diameter(self):
+        return self.radius * 2
+
+    @functools.cached_property
+    def area(self) -> float:
+        print("computing area")
+        return 3.14159 * self.radius ** 2
+
+c = circle(2)
+print(c.area)
+print(c.area) # computed only once
+
+class temperature(object):
+    def __init__(self):
+        self._celsius = 0
+
+    @property
+    def celsius(self):
+        return self._celsius
+
+    @celsius.setter
+    def celsius(self, value):
+        if value < -273.15:
+            raise ValueError("below absolute zero")
+        self._celsius = value
+
+t = temperature()
+t.celsius = 25
+t.celsius += 5
+print(t.celsius)
@@ -0,0 +1,9 @@
+import random
+
+random.seed(1)
+print(random.random(), random.randint(1, 6))
+
+colors = ["red", "green", "blue"]
+print(random.choice(colors))
+random.shuffle(colors)
+print(colors)
@@ -1,3 +1,26 @@
 # test dict iterator
 for k, v in {"a":1, "b":2, "c":3}.items():
     print(k, v)
+
+l = ["a", "b", "c"]
+
+for i in reversed(range(10)):
+    print(i)
+
+for i in reversed(range(2, 5)):
+    print(i)
+
+for x in reversed(l):
+    print(x)
+
+for i, x in enumerate(reversed(l)):
+    print(i, x)
+
+print(reversed(l))
+
+for i in range(10, 0, -3):
+    print(i)
+
+step = -2 if len(l) > 2 else 2
+for i in range(0, 10, step):  # the direction is known only at runtime
+    print(i)
@@ -0,0 +1,20 @@
+# functions returning multiple values
+
+def divmod2(a, b):
+    if b == 0:
+        return 0, 0
+    return a // b, a % b
+
+q, r = divmod2(7, 2)
+print(q, r)
+
+# a single target collects the values in a tuple
+res = divmod2(9, 4)
+print(res[0], res[1])
+
+def maybe_pair(ok):
+    if ok:
+        return 1, 2
+    return None  # not always a tuple: a single value
+
+print(maybe_pair(True))
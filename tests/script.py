@@ -0,0 +1,18 @@
+# a script without a __main__ guard: the top level statements are in main()
+# (in order, so an assignment after other statements is also in main)
+
+items = []
+for i in range(3):
+    items.append(i * i)
+
+total = sum(items)
+print(items, total)
+
+count = 0
+count = count + 1
+print(count)
+
+def helper(x):
+    return x + total
+
+print(helper(1))
@@ -0,0 +1,14 @@
+# test builtin names used as variables
+
+list = [1, 2, 3]
+print(list, len(list))
+
+type = "foo"
+if type == "foo":
+    print(type)
+
+def describe(str, dict=None):
+    print(str, dict)
+
+describe("x")
+values = dict(a=1)
@@ -8,3 +8,21 @@ print(s[:5])
 print(s[:-5])
 
 print(s[-4])
+
+a = [1, 2, 3, 4, 5]
+
+print(a[-1])
+print(a[1])
+
+a[1:3] = [9, 9]
+a[:2] = [0]
+a[3:] = []
+
+# string slices are on runes, with python bounds
+word = "héllo"
+print(word[:-1])
+print(word[-3:])
+print(word[1:3])
+
+data = b"bytes"
+print(data[1:3])
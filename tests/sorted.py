@@ -0,0 +1,25 @@
+class person(object):
+    def __init__(self, name, age):
+        self.name = name
+        self.age = age
+
+people = [person("bob", 30), person("alice", 25)]
+
+for p in sorted(people, key=lambda x: x.age, reverse=True):
+    print(p.name)
+
+words = ["banana", "Apple", "cherry"]
+print(sorted(words))
+print(sorted(words, key=str.lower))
+print(sorted(words, key=len, reverse=True))
+
+ages = {"bob": 30, "alice": 25}
+for name, age in sorted(ages.items()):
+    print(name, age)
+
+for name, age in sorted(ages.items(), key=lambda kv: kv[1], reverse=True):
+    print(name, age)
+
+print(list(ages.items()))
+
+print(sorted(ages), sorted("cab"))  # the keys of a dict, the characters of a string
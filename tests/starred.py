@@ -0,0 +1,10 @@
+# test starred expressions in list and tuple literals
+
+a = [1, 2]
+b = [3, 4]
+rest = (5, 6)
+
+print([*a, *b])
+print((*a, 0))
+print([0, *rest, 9])
+print([*"xy", *a])
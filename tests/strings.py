@@ -0,0 +1,49 @@
+s = "hello world"
+
+print(s.find("o"), s.rfind("o"))
+print(s.find("o", 5), s.rfind("o", 0, 5))
+print(s.index("world"))
+
+print("42".zfill(5), "-42".zfill(5))
+print("[" + "title".center(11, "=") + "]")
+print("name".ljust(8) + "|", "10".rjust(4, "0"))
+
+text = "first line\r\nsecond line\n"
+for line in text.splitlines():
+    print(line)
+
+print(text.splitlines(keepends=True))
+
+key, _, value = "name=value".partition("=")
+print(key, value)
+print("a.b.c".rpartition("."))
+
+for c in "héllo":
+    print(c)
+
+word = "abc"
+codes = [ord(c) for c in word]
+print(codes, chr(codes[0]))
+
+# chained string methods
+line = "  Apple,Banana,Cherry  "
+fields = line.strip().lower().split(",")
+# strings.Split(strings.ToLower(strings.TrimSpace(line)), ",")
+print(fields)
+print(line.strip().zfill(30).upper())
+print(line.strip().lower().find("banana"))
+
+# join over generators, comprehensions and map
+nums = [1, 2, 3]
+print(",".join(str(x) for x in nums))
+print("-".join([str(x * 2) for x in nums if x > 1]))
+print(" ".join(map(str, nums)))
+print("|".join(w.upper() for w in ["a", "b"]))
+
+# find and slices use the same (rune) positions
+pair = "clé=valeur"
+print(pair[pair.find("=") + 1:])
+
+# not a string: a method call
+def first_item(tree):
+    return tree.find("item")
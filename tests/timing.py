@@ -0,0 +1,9 @@
+import time
+
+start = time.perf_counter()
+time.sleep(0.1)
+elapsed = time.perf_counter() - start
+print("elapsed %.3f" % elapsed)
+
+t0 = time.monotonic()
+print(time.monotonic() - t0 >= 0)
@@ -0,0 +1,42 @@
+# test truthiness of conditions
+
+items = []
+name = "pygor"
+count = 0
+
+if not items:
+    print("no items")
+
+if items:
+    print("some items")
+
+if name and not count:
+    print(name)
+
+def check(x, l: list):
+    if x:
+        print("x is true")
+
+    while not l:
+        l.append(x)
+
+print(bool(0), bool("x"), bool(None), bool())
+
+flag = bool(items)
+maybe = bool(check(count, items))
+
+# untyped parameters and loop variables hide the module names: runtime.Truthy(name)
+def greet(name):
+    if name:
+        print("hello", name)
+
+for count in [None, 1]:
+    if count:
+        print(count)
+
+# reassigned in the loop: runtime.Truthy(last), not false
+last = None
+for v in [1, 2]:
+    last = v
+if last:
+    print(last)
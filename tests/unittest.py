@@ -0,0 +1,20 @@
+# test unittest assertions
+
+import unittest
+
+def parse(s):
+    return int(s)
+
+class TestParse(unittest.TestCase):
+    def test_values(self):
+        self.assertEqual(parse("42"), 42)
+        self.assertNotEqual(parse("1"), 2, "one is not two")
+        self.assertTrue(parse("1"))
+        self.assertFalse(parse("0"), msg="zero is false")
+        self.assertIn(3, [1, 2, 3])
+        self.assertIsNone(None)
+
+    def test_errors(self):
+        self.assertRaises(ValueError, parse, "x")
+        with self.assertRaises(ValueError):
+            parse("y")
@@ -3,3 +3,60 @@
 with open("test.txt") as f:
     f.write("hello worlds")
     f.read()
+
+for name in ["a.txt", "b.txt", "c.txt"]:
+    with open(name) as f:
+        if f.read() == "":
+            continue
+        if f.read() == "stop":
+            break
+        print(name)
+
+with open("test.txt") as f:
+    for line in f:
+        print(line)
+
+import contextlib
+from contextlib import suppress
+
+with suppress(FileNotFoundError):
+    with open("missing.txt") as f:
+        print(f.read())
+
+with contextlib.suppress(KeyError, IndexError):
+    print({}["a"])
+
+with contextlib.nullcontext(42) as n:
+    print(n)
+
+class Timer:
+    def __enter__(self):
+        print("start")
+        return self
+
+    def __exit__(self, exc_type, exc, tb):
+        print("stop")
+
+with Timer() as t:
+    print("timing", t)
+
+# multiple items: the files are closed in reverse order (dst, then src)
+with open("src.txt") as src, open("dst.txt") as dst:
+    dst.write(src.read())
+
+with open("a.txt") as a, Timer() as t:
+    print(a.read(), t)
+
+# a raise (a return) in the body: no function literal
+def first_line(name):
+    with open(name) as f:
+        line = f.readline()
+        if not line:
+            raise ValueError("empty file")
+        return line
+
+def timed(n):
+    with Timer():
+        if n < 0:
+            return None
+        return n * 2
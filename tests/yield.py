@@ -6,3 +6,31 @@ def gen(n):
 
 for x in gen(5):
     print(x)
+
+def positive(l):
+    for i in l:
+        assert i > 0, "not positive"
+        yield i
+
+for x in positive([1, 2, 3]):
+    print(x)
+
+def accumulate():
+    total = 0
+    while True:
+        value = yield total
+        if value is not None:
+            total += value
+
+g = gen(2)
+print(next(g), next(g))
+print(next(g, "done"))
+
+try:
+    next(g)
+except StopIteration:
+    print("stop")
+
+acc = accumulate()
+next(acc)
+print(acc.send(10))  # not supported: the value is ignored